@@ -0,0 +1,123 @@
+package mobiledetect
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// BatchItem is a User-Agent to detect in a batch, along with the headers sent with it, if any.
+type BatchItem struct {
+	UserAgent string
+	Headers   http.Header
+}
+
+// request builds the request the detection runs on.
+func (item BatchItem) request() *http.Request {
+	header := make(http.Header, len(item.Headers)+1)
+	for name, values := range item.Headers {
+		header[http.CanonicalHeaderKey(name)] = values
+	}
+	header.Set("User-Agent", item.UserAgent)
+	return &http.Request{Method: "GET", Header: header}
+}
+
+// batchWorkers returns the number of goroutines to use, one per CPU when workers is 0 or less.
+func batchWorkers(workers int) int {
+	if workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
+
+// DetectBatch detects the items with the given number of goroutines, one per CPU when workers is 0 or less,
+// and returns the results in the order of the items. When ctx is done before the end, the results of the items
+// not detected yet are nil and the error of ctx is returned.
+func (d *Detector) DetectBatch(ctx context.Context, items []BatchItem, workers int) ([]*Result, error) {
+	results := make([]*Result, len(items))
+	workers = batchWorkers(workers)
+	if workers > len(items) {
+		workers = len(items)
+	}
+	var next int64 = -1
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nil == ctx.Err() {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(items) {
+					return
+				}
+				results[i] = d.Detect(items[i].request())
+			}
+		}()
+	}
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// DetectStream detects the items received with the given number of goroutines, one per CPU when workers
+// is 0 or less, and sends the results in the order of the items. The results channel is closed once items
+// is closed and every result is sent, or as soon as ctx is done: ctx.Err() then tells whether some are missing.
+// The results must be read until the channel is closed, or ctx cancelled, for the goroutines to end.
+func (d *Detector) DetectStream(ctx context.Context, items <-chan BatchItem, workers int) <-chan *Result {
+	type job struct {
+		item   BatchItem
+		result chan *Result
+	}
+	workers = batchWorkers(workers)
+	jobs := make(chan job, workers)
+	// the results of the jobs in flight, in the order of the items
+	pending := make(chan chan *Result, workers)
+	results := make(chan *Result, workers)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for j := range jobs {
+				j.result <- d.Detect(j.item.request())
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for {
+			var item BatchItem
+			var ok bool
+			select {
+			case item, ok = <-items:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+			// the workers never block, so the job is always detected and its result always sent
+			j := job{item: item, result: make(chan *Result, 1)}
+			jobs <- j
+			select {
+			case pending <- j.result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(results)
+		for result := range pending {
+			select {
+			case results <- <-result:
+			case <-ctx.Done():
+				// let the dispatcher finish
+				for range pending {
+				}
+				return
+			}
+		}
+	}()
+	return results
+}
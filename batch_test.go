@@ -0,0 +1,109 @@
+package mobiledetect
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func batchItems(n int) ([]BatchItem, []string) {
+	userAgents := []string{iPhoneUserAgent, iPadUserAgent, desktopUserAgent}
+	devices := []string{DEVICE_MOBILE, DEVICE_TABLET, DEVICE_DESKTOP}
+	items := make([]BatchItem, n)
+	expected := make([]string, n)
+	for i := range items {
+		items[i] = BatchItem{UserAgent: userAgents[i%3]}
+		expected[i] = devices[i%3]
+	}
+	return items, expected
+}
+
+func TestDetectBatch(t *testing.T) {
+	items, expected := batchItems(100)
+	items = append(items, BatchItem{Headers: http.Header{"x-wap-profile": {"http://wap.samsungmobile.com/uaprof/SGH-I777.xml"}}})
+	expected = append(expected, DEVICE_MOBILE)
+	for _, workers := range []int{0, 1, 7, 500} {
+		results, err := NewDetector(WithCache(NewCache(10)), WithTrustedHeaders(DefaultTrustedHeaders()...)).DetectBatch(context.Background(), items, workers)
+		if nil != err {
+			t.Fatal(err)
+		}
+		for i, res := range results {
+			if expected[i] != res.Device() {
+				t.Errorf("%d workers: expected %s got %s for item %d", workers, expected[i], res.Device(), i)
+			}
+		}
+	}
+	if results, err := NewDetector().DetectBatch(context.Background(), nil, 4); nil != err || 0 != len(results) {
+		t.Errorf("Expected no results got %v (%v)", results, err)
+	}
+}
+
+func TestDetectBatchCancelled(t *testing.T) {
+	items, _ := batchItems(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := NewDetector().DetectBatch(ctx, items, 4)
+	if context.Canceled != err {
+		t.Errorf("Expected %v got %v", context.Canceled, err)
+	}
+	if len(items) != len(results) || nil != results[len(results)-1] {
+		t.Errorf("Expected the items not to be detected")
+	}
+}
+
+func TestDetectStream(t *testing.T) {
+	items, expected := batchItems(1000)
+	in := make(chan BatchItem)
+	go func() {
+		for _, item := range items {
+			in <- item
+		}
+		close(in)
+	}()
+	i := 0
+	for res := range NewDetector().DetectStream(context.Background(), in, 8) {
+		if expected[i] != res.Device() {
+			t.Errorf("Expected %s got %s for item %d", expected[i], res.Device(), i)
+		}
+		i++
+	}
+	if len(items) != i {
+		t.Errorf("Expected %d results got %d", len(items), i)
+	}
+}
+
+func TestDetectStreamCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// never closed: only the cancellation ends the stream
+	in := make(chan BatchItem)
+	go func() {
+		for {
+			select {
+			case in <- BatchItem{UserAgent: iPhoneUserAgent}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	results := NewDetector().DetectStream(ctx, in, 4)
+	for i := 0; i < 10; i++ {
+		if res := <-results; !res.Mobile {
+			t.Errorf("Unexpected result %+v", res)
+		}
+	}
+	cancel()
+	for range results {
+	}
+	if context.Canceled != ctx.Err() {
+		t.Errorf("Expected the stream to end after the cancellation")
+	}
+}
+
+func BenchmarkDetectBatch(b *testing.B) {
+	items, _ := batchItems(300)
+	detector := NewDetector()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		detector.DetectBatch(context.Background(), items, 0)
+	}
+}
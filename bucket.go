@@ -0,0 +1,74 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Buckets group devices coarsely enough for caches to keep a single copy of a page per bucket.
+const (
+	BUCKET_PHONE   = "phone"
+	BUCKET_TABLET  = "tablet"
+	BUCKET_DESKTOP = "desktop"
+	BUCKET_BOT     = "bot"
+)
+
+// The request header WithBucketHeader is usually given.
+const DEVICE_BUCKET_HEADER = "X-Device-Bucket"
+
+// Bucket returns the bucket of the device: phone, tablet, desktop or bot. With grade, the grade of phones
+// and tablets is appended, e.g. phone-a, so that caches can keep a lighter page for older devices apart.
+// Unlike the User-Agent, the bucket only takes a handful of values, making it a good key for caches.
+func (res *Result) Bucket(grade bool) string {
+	var bucket string
+	switch {
+	case !res.Overridden && res.IsBot():
+		return BUCKET_BOT
+	case res.Tablet:
+		bucket = BUCKET_TABLET
+	case res.Mobile:
+		bucket = BUCKET_PHONE
+	default:
+		return BUCKET_DESKTOP
+	}
+	if grade && "" != res.Grade {
+		bucket += "-" + strings.ToLower(res.Grade)
+	}
+	return bucket
+}
+
+// Bucket returns the bucket of the device of the request, see Result.Bucket. It uses the result
+// in the request context when there is one, and otherwise runs the detection with the given options.
+func Bucket(r *http.Request, grade bool, opts ...Option) string {
+	res, ok := FromContext(r.Context())
+	if !ok || (grade && !res.detailed) {
+		o := newOptions(nil, append([]Option{WithDetails(grade)}, opts...))
+		if ok {
+			res = o.detailed(r, res)
+		} else {
+			_, res = o.detect(r)
+		}
+	}
+	return res.Bucket(grade)
+}
+
+// WithBucketHeader makes Handler, HandlerMux and Middleware set the request header name, such as
+// DEVICE_BUCKET_HEADER, to the bucket of the device before handing the request on, replacing any value
+// sent by the client. In front of an httputil.ReverseProxy, upstream caches can then vary on the bucket
+// rather than on the User-Agent; WithVary(false) keeps the User-Agent out of the Vary of the responses.
+func WithBucketHeader(name string, grade bool) Option {
+	return func(o *options) {
+		o.bucketHeader = name
+		o.bucketGrade = grade
+		o.details = o.details || grade
+	}
+}
+
+// setBucketHeader tells the next handlers the bucket of the device, if asked to.
+// r must be a copy of the incoming request, whose headers are left alone.
+func (o *options) setBucketHeader(r *http.Request, res *Result) {
+	if "" != o.bucketHeader {
+		r.Header = r.Header.Clone()
+		r.Header.Set(o.bucketHeader, res.Bucket(o.bucketGrade))
+	}
+}
@@ -0,0 +1,94 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	googlebotUserAgent           = `Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)`
+	googlebotSmartphoneUserAgent = `Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/41.0.2272.96 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)`
+	chromeDesktopUserAgent       = `Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/61.0.3163.100 Safari/537.36`
+)
+
+func TestBucket(t *testing.T) {
+	data := []struct {
+		userAgent string
+		bucket    string
+		graded    string
+	}{
+		{iPhoneUserAgent, BUCKET_PHONE, "phone-a"},
+		{iPadUserAgent, BUCKET_TABLET, "tablet-a"},
+		{desktopUserAgent, BUCKET_DESKTOP, BUCKET_DESKTOP},
+		{googlebotUserAgent, BUCKET_BOT, BUCKET_BOT},
+		{googlebotSmartphoneUserAgent, BUCKET_BOT, BUCKET_BOT},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		if bucket := Bucket(r, false); d.bucket != bucket {
+			t.Errorf("%s: expected bucket %s got %s", d.userAgent, d.bucket, bucket)
+		}
+		if bucket := Bucket(r, true); d.graded != bucket {
+			t.Errorf("%s: expected graded bucket %s got %s", d.userAgent, d.graded, bucket)
+		}
+	}
+}
+
+func TestBucketFromContext(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", desktopUserAgent)
+	r = r.WithContext(NewContext(r.Context(), &Result{Mobile: true, Tablet: true}))
+	if BUCKET_TABLET != Bucket(r, false) {
+		t.Error("Bucket should use the result in the context")
+	}
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	r = r.WithContext(NewContext(r.Context(), NewDetector(WithDetails(false)).Detect(r)))
+	if "phone-a" != Bucket(r, true) {
+		t.Errorf("Expected the grade to be detected, got %s", Bucket(r, true))
+	}
+}
+
+func TestBucketHeader(t *testing.T) {
+	var bucket string
+	h := Middleware(WithBucketHeader(DEVICE_BUCKET_HEADER, false), WithOverride(overrideConfig))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bucket = r.Header.Get(DEVICE_BUCKET_HEADER)
+	}))
+	data := []struct {
+		url, userAgent, bucket string
+	}{
+		{"/", iPhoneUserAgent, BUCKET_PHONE},
+		{"/", googlebotSmartphoneUserAgent, BUCKET_BOT},
+		{"/?device=desktop", iPhoneUserAgent, BUCKET_DESKTOP},
+		{"/?device=tablet", googlebotUserAgent, BUCKET_TABLET},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", d.url, nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		r.Header.Set(DEVICE_BUCKET_HEADER, "spoofed")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		if d.bucket != bucket {
+			t.Errorf("%s %s: expected %s got %s", d.url, d.userAgent, d.bucket, bucket)
+		}
+		if "spoofed" != r.Header.Get(DEVICE_BUCKET_HEADER) {
+			t.Error("The headers of the incoming request should be left alone")
+		}
+	}
+}
+
+func TestUtilitiesAreNotMobile(t *testing.T) {
+	detect := NewMobileDetect(httpRequest, nil)
+	detect.SetUserAgent(chromeDesktopUserAgent + " HbbTV/1.1.1")
+	if !detect.IsKey(TV) {
+		t.Error("HbbTV should be a TV")
+	}
+	if detect.IsMobile() || detect.IsTablet() {
+		t.Error("Utilities should take no part in IsMobile nor IsTablet")
+	}
+	detect.SetUserAgent(googlebotUserAgent)
+	if !detect.IsKey(BOT) || !detect.Is("bot") || detect.IsMobile() {
+		t.Error("Googlebot should be a bot, and not mobile")
+	}
+}
@@ -0,0 +1,138 @@
+package mobiledetect
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+// Cache keeps the results of the most recently detected requests, so that a User-Agent seen
+// over and over again is only matched against the rules once. It is bounded to a number of
+// results, evicting the least recently used one first, and is safe for concurrent use.
+//
+// Results are kept apart by the rules and carriers they were computed with, so that a cache may be shared by
+// detectors using different ones.
+type Cache struct {
+	mu     sync.Mutex
+	size   int
+	ll     *list.List
+	items  map[string]*list.Element
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	key    string
+	result *Result
+}
+
+// NewCache creates a cache holding up to size results.
+func NewCache(size int) *Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *Cache) get(key string) (*Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		c.hits++
+		return e.Value.(*cacheEntry).result, true
+	}
+	c.misses++
+	return nil, false
+}
+
+func (c *Cache) add(key string, result *Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*cacheEntry).result = result
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key, result})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// Len returns the number of results currently cached.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Purge removes all the cached results, e.g. after the rules were reloaded. Counters are kept.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element, c.size)
+}
+
+// Hits returns how many lookups found a cached result.
+func (c *Cache) Hits() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits
+}
+
+// Misses returns how many lookups had to run the detection.
+func (c *Cache) Misses() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.misses
+}
+
+// cacheKey identifies the inputs of the detection: the rules, the carriers, the User-Agent, the proxy it was forwarded by and the headers IsMobile, ProxyMode and Carrier look at.
+func (md *MobileDetect) cacheKey() string {
+	// a cache may be shared by detectors with different rules or carriers
+	key := []string{md.rules.fingerprint(), carriersKey(md.options.carriers), md.userAgent}
+	if md.options.details {
+		// results with and without details must not be mixed up
+		key = append(key, "details")
+	}
+	if "" != md.forwardedBy {
+		key = append(key, md.forwardedBy+":"+md.proxyUserAgent)
+	}
+	for _, mobileHeader := range md.options.inputHeaders() {
+		if headerString, ok := md.httpHeader(mobileHeader); ok {
+			key = append(key, mobileHeader+":"+headerString)
+		}
+	}
+	return strings.Join(key, "\n")
+}
+
+// carriersKey identifies the mapping of headers to carriers.
+func carriersKey(carriers []Carrier) string {
+	var b strings.Builder
+	for _, c := range carriers {
+		b.WriteString(c.Header + "=" + c.Name)
+		if c.DeviceID {
+			b.WriteString("+id")
+		}
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+// inputHeaders returns the headers the results depend on, as they are found in the http headers.
+func (o *options) inputHeaders() []string {
+	names := append(mobileHeaderNames(), o.carrierHeaders()...)
+	if o.details {
+		// ProxyMode is only worked out along with the details
+		names = append(names, proxyBrowserHeaders()...)
+	}
+	return names
+}
@@ -0,0 +1,135 @@
+package mobiledetect
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestCacheEviction(t *testing.T) {
+	c := NewCache(2)
+	c.add("a", &Result{UserAgent: "a"})
+	c.add("b", &Result{UserAgent: "b"})
+	if _, ok := c.get("a"); !ok {
+		t.Error("a should be cached")
+	}
+	// b is now the least recently used
+	c.add("c", &Result{UserAgent: "c"})
+	if _, ok := c.get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if res, ok := c.get("c"); !ok || "c" != res.UserAgent {
+		t.Error("c should be cached")
+	}
+	if 2 != c.Len() {
+		t.Errorf("Cache should hold 2 results, not %d", c.Len())
+	}
+	if 2 != c.Hits() || 1 != c.Misses() {
+		t.Errorf("Expected 2 hits and 1 miss, got %d and %d", c.Hits(), c.Misses())
+	}
+
+	c.Purge()
+	if 0 != c.Len() {
+		t.Error("Cache should be empty after Purge")
+	}
+	if _, ok := c.get("a"); ok {
+		t.Error("a should have been purged")
+	}
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	c := NewCache(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				key := fmt.Sprintf("%d", (i*j)%20)
+				if _, ok := c.get(key); !ok {
+					c.add(key, &Result{UserAgent: key})
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if c.Len() > 10 {
+		t.Errorf("Cache grew over its size: %d", c.Len())
+	}
+	if 800 != c.Hits()+c.Misses() {
+		t.Errorf("Expected 800 lookups, got %d", c.Hits()+c.Misses())
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	detect := NewMobileDetect(httpRequest, nil)
+	detect.SetUserAgent("Mozilla/5.0")
+	detect.SetHttpHeaders(map[string]string{"HTTP_REFERER": "http://mobiledetect.net"})
+	plain := detect.cacheKey()
+	detect.SetHttpHeaders(map[string]string{"HTTP_REFERER": "http://example.com"})
+	if plain != detect.cacheKey() {
+		t.Error("Headers which are not looked at should not change the key")
+	}
+	detect.SetHttpHeaders(map[string]string{"HTTP_X_WAP_PROFILE": "http://nds.nokia.com/uaprof/N6230r200.xml"})
+	if plain == detect.cacheKey() {
+		t.Error("Mobile headers should change the key")
+	}
+	detailed := NewMobileDetect(httpRequest, nil, WithDetails(true))
+	detailed.SetUserAgent("Mozilla/5.0")
+	detailed.SetHttpHeaders(map[string]string{"HTTP_REFERER": "http://mobiledetect.net"})
+	if plain == detailed.cacheKey() {
+		t.Error("Results with details should not share the key of results without")
+	}
+}
+
+func TestCacheSharedByRules(t *testing.T) {
+	other := NewRules()
+	other.combined = append([]string(nil), other.combined...)
+	other.combined[0] += "|NeverSeen"
+	cache := NewCache(10)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	NewDetector(WithCache(cache)).Detect(r)
+	NewDetector(WithCache(cache), WithRules(other)).Detect(r)
+	if 2 != cache.Misses() || 2 != cache.Len() {
+		t.Errorf("Expected the results of other rules to be kept apart, got %d misses and %d results", cache.Misses(), cache.Len())
+	}
+}
+
+func TestCacheSharedByCarriers(t *testing.T) {
+	cache := NewCache(10)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	r.Header.Set("X-Orange-Id", "123")
+	trusted := WithTrustedHeaders(DefaultTrustedHeaders()...)
+	NewDetector(WithCache(cache), trusted).Detect(r)
+	res := NewDetector(WithCache(cache), trusted, WithCarriers(Carrier{Header: "X-Orange-Id", Name: "Other"})).Detect(r)
+	if "Other" != res.Carrier || "" != res.CarrierDeviceID {
+		t.Errorf("Expected the results of other carriers to be kept apart, got %q %q", res.Carrier, res.CarrierDeviceID)
+	}
+}
+
+func TestHandlerWithCache(t *testing.T) {
+	cache := NewCache(10)
+	deviceHandler := &basicMethodsStruct{}
+	s := httptest.NewServer(Handler(deviceHandler, nil, WithCache(cache)))
+	defer s.Close()
+	req, _ := http.NewRequest("GET", s.URL, nil)
+	req.Header.Set("User-Agent", `Mozilla/5.0 (iPad; CPU OS 5_1_1 like Mac OS X; en-us) AppleWebKit/534.46.0 (KHTML, like Gecko) CriOS/21.0.1180.80 Mobile/9B206 Safari/7534.48.3 (6FF046A0-1BC4-4E7D-8A9D-6BF17622A123)`)
+	c := http.Client{}
+	for i := 0; i < 3; i++ {
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if "tablet" != deviceHandler.handlerCalled {
+			t.Errorf("actual: %s instead: tablet", deviceHandler.handlerCalled)
+		}
+	}
+	if 1 != cache.Misses() || 2 != cache.Hits() {
+		t.Errorf("Expected 1 miss and 2 hits, got %d and %d", cache.Misses(), cache.Hits())
+	}
+}
@@ -0,0 +1,61 @@
+package mobiledetect
+
+// Carrier tells the mobile operator, or operator gateway, behind a request from a header its gateways add.
+type Carrier struct {
+	// Header added by the gateways, e.g. X-Orange-Id. It must be trusted, see WithTrustedHeaders.
+	Header string
+	// Name of the operator or gateway, e.g. Orange
+	Name string
+	// DeviceID is true when the value of the header identifies the device or the subscriber,
+	// and is reported as such in Result.CarrierDeviceID.
+	DeviceID bool
+}
+
+// The carriers WithCarriers is not given, tried in order.
+var defaultCarriers = []Carrier{
+	{"X-Att-Deviceid", "AT&T", true},
+	{"X-Orange-Id", "Orange", true},
+	{"X-Vodafone-3gpdpcontext", "Vodafone", false},
+	{"X-Huawei-Userid", "Huawei", true},
+	{"X-Nokia-Gateway-Id", "Nokia", false},
+}
+
+// DefaultCarriers returns the carriers known by default, to be extended and given to WithCarriers.
+func DefaultCarriers() []Carrier {
+	return append([]Carrier(nil), defaultCarriers...)
+}
+
+// WithCarriers sets the carriers Carrier looks for, the first one whose header is present winning. Their headers
+// are only read when trusted, so the ones of custom carriers must be added to WithTrustedHeaders too:
+//
+//	carriers := append(mobiledetect.DefaultCarriers(), mobiledetect.Carrier{Header: "X-Up-Subno", Name: "Openwave", DeviceID: true})
+//	trusted := append(mobiledetect.DefaultTrustedHeaders(), "X-Up-Subno")
+//	mobiledetect.Middleware(mobiledetect.WithCarriers(carriers...), mobiledetect.WithTrustedHeaders(trusted...))
+func WithCarriers(carriers ...Carrier) Option {
+	return func(o *options) {
+		o.carriers = carriers
+	}
+}
+
+// carrierHeaders returns the headers of the carriers, as they are found in the http headers.
+func (o *options) carrierHeaders() []string {
+	names := make([]string, 0, len(o.carriers))
+	for _, c := range o.carriers {
+		names = append(names, cgiHeaderName(c.Header))
+	}
+	return names
+}
+
+// Carrier returns the name of the operator or gateway the request went through, and the identifier of the
+// device it sent, if any. Both are empty when no header of the carriers was sent, see WithCarriers.
+func (md *MobileDetect) Carrier() (string, string) {
+	for _, c := range md.options.carriers {
+		if value, ok := md.httpHeader(cgiHeaderName(c.Header)); ok && "" != value {
+			if !c.DeviceID {
+				value = ""
+			}
+			return c.Name, value
+		}
+	}
+	return "", ""
+}
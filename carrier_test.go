@@ -0,0 +1,68 @@
+package mobiledetect
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCarrier(t *testing.T) {
+	openwave := Carrier{Header: "X-Up-Subno", Name: "Openwave", DeviceID: true}
+	data := []struct {
+		opts     []Option
+		headers  map[string]string
+		carrier  string
+		deviceID string
+	}{
+		{nil, map[string]string{"X-Orange-Id": "12345"}, "Orange", "12345"},
+		{nil, map[string]string{"X-ATT-DeviceId": "HTC/SensationXE_Beats_Z715e/3.33.163.52"}, "AT&T", "HTC/SensationXE_Beats_Z715e/3.33.163.52"},
+		{nil, map[string]string{"X-Vodafone-3gpdpcontext": "1"}, "Vodafone", ""},
+		{nil, map[string]string{"X-Nokia-Gateway-Id": "NWG/4.1/Build4.1.02"}, "Nokia", ""},
+		{nil, map[string]string{"X-Orange-Id": ""}, "", ""},
+		{nil, map[string]string{"X-Up-Subno": "42"}, "", ""},
+		{[]Option{WithCarriers(append(DefaultCarriers(), openwave)...), WithTrustedHeaders("X-Up-Subno")}, map[string]string{"X-Up-Subno": "42"}, "Openwave", "42"},
+		// untrusted
+		{[]Option{WithCarriers(openwave)}, map[string]string{"X-Up-Subno": "42"}, "", ""},
+		{[]Option{WithCarriers()}, map[string]string{"X-Orange-Id": "12345"}, "", ""},
+		{[]Option{WithTrustedHeaders()}, map[string]string{"X-Orange-Id": "12345"}, "", ""},
+		{nil, nil, "", ""},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", desktopUserAgent)
+		for name, value := range d.headers {
+			r.Header.Set(name, value)
+		}
+		md := NewMobileDetect(r, nil, append([]Option{WithTrustedHeaders(DefaultTrustedHeaders()...)}, d.opts...)...)
+		if carrier, deviceID := md.Carrier(); d.carrier != carrier || d.deviceID != deviceID {
+			t.Errorf("Expected %q %q got %q %q for %v", d.carrier, d.deviceID, carrier, deviceID, d.headers)
+		}
+		if res := md.Result(); d.carrier != res.Carrier || d.deviceID != res.CarrierDeviceID {
+			t.Errorf("Expected the result to have %q %q got %q %q", d.carrier, d.deviceID, res.Carrier, res.CarrierDeviceID)
+		}
+	}
+	if len(defaultCarriers) != len(DefaultCarriers()) || &defaultCarriers[0] == &DefaultCarriers()[0] {
+		t.Errorf("Expected a copy of the default carriers")
+	}
+}
+
+func TestCarrierCacheKey(t *testing.T) {
+	detector := NewDetector(WithCache(NewCache(10)), WithCarriers(Carrier{Header: "X-Up-Subno", Name: "Openwave", DeviceID: true}), WithTrustedHeaders("X-Up-Subno"))
+	for _, subscriber := range []string{"1", "2"} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", iPhoneUserAgent)
+		r.Header.Set("X-Up-Subno", subscriber)
+		if res := detector.Detect(r); subscriber != res.CarrierDeviceID {
+			t.Errorf("Expected %q got %q", subscriber, res.CarrierDeviceID)
+		}
+	}
+}
+
+func TestCarrierUntrusted(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", desktopUserAgent)
+	r.Header.Set("X-Orange-Id", "123")
+	res := NewDetector(WithTrustedHeaders(), WithCarriers(DefaultCarriers()...)).Detect(r)
+	if res.Mobile || "" != res.Carrier {
+		t.Errorf("Expected the headers of the carriers to be ignored unless trusted, got %+v", res)
+	}
+}
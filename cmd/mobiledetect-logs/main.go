@@ -0,0 +1,230 @@
+// Command mobiledetect-logs reports the devices, operating systems, browsers and grades found in access logs.
+//
+//	mobiledetect-logs /var/log/nginx/access.log /var/log/nginx/access.log.1.gz
+//	mobiledetect-logs -log json -ua-field agent -format csv < access.json > devices.csv
+//
+// Logs are in the combined format of Nginx and Apache, or JSON objects, one per line; by default the format
+// is worked out line by line. Headers logged along with the User-Agent, such as X-Wap-Profile, can be named with
+// -headers, and are then trusted by the detection: in combined logs they are the quoted fields following the User-Agent.
+// Lines are read from the files given, which may be gzipped, or from the standard input, and detected by a pool of workers.
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Shaked/gomobiledetect"
+)
+
+const (
+	// Lines longer than this are skipped.
+	maxLineLength = 64 * 1024
+	// Lines are handed to the workers by batches of this many.
+	batchSize = 256
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("mobiledetect-logs", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	logFormat := flags.String("log", "auto", "log format: auto, combined or json")
+	uaField := flags.String("ua-field", "", "field holding the User-Agent in JSON logs (default: http_user_agent, user_agent, userAgent, ua or agent)")
+	headerList := flags.String("headers", "", "comma separated headers logged after the User-Agent, e.g. X-Wap-Profile")
+	format := flags.String("format", "text", "report format: text, csv or json")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of workers detecting devices")
+	cacheSize := flags.Int("cache", 10000, "number of User-Agents whose results are cached")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mobiledetect-logs [flags] [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return 2
+	}
+
+	var headers []string
+	for _, name := range strings.Split(*headerList, ",") {
+		if name = strings.TrimSpace(name); "" != name {
+			headers = append(headers, http.CanonicalHeaderKey(name))
+		}
+	}
+	var parse parser
+	switch *logFormat {
+	case "auto":
+		parse = autoParser(combinedParser(headers), jsonParser(*uaField, headers))
+	case "combined":
+		parse = combinedParser(headers)
+	case "json":
+		parse = jsonParser(*uaField, headers)
+	default:
+		fmt.Fprintf(stderr, "mobiledetect-logs: unknown log format %q\n", *logFormat)
+		return 2
+	}
+	var write func(*stats, io.Writer) error
+	switch *format {
+	case "text":
+		write = (*stats).writeText
+	case "csv":
+		write = (*stats).writeCSV
+	case "json":
+		write = (*stats).writeJSON
+	default:
+		fmt.Fprintf(stderr, "mobiledetect-logs: unknown format %q\n", *format)
+		return 2
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+	opts := []mobiledetect.Option{mobiledetect.WithTrustedHeaders(headers...)}
+	if *cacheSize > 0 {
+		opts = append(opts, mobiledetect.WithCache(mobiledetect.NewCache(*cacheSize)))
+	}
+
+	batches := make(chan []string, *workers)
+	results := make(chan *stats, *workers)
+	a := &analyzer{parse: parse, detector: mobiledetect.NewDetector(opts...)}
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- a.work(batches)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var err error
+	if 0 == flags.NArg() {
+		err = readBatches(stdin, batches)
+	} else {
+		for _, name := range flags.Args() {
+			if err = readFile(name, batches); nil != err {
+				break
+			}
+		}
+	}
+	close(batches)
+	total := newStats()
+	for s := range results {
+		total.merge(s)
+	}
+	if nil == err {
+		err = write(total, stdout)
+	}
+	if nil != err {
+		fmt.Fprintf(stderr, "mobiledetect-logs: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// analyzer is shared by the workers.
+type analyzer struct {
+	parse    parser
+	detector *mobiledetect.Detector
+}
+
+// work detects the lines of the batches until there are no more, and returns their counts.
+func (a *analyzer) work(batches <-chan []string) *stats {
+	s := newStats()
+	for batch := range batches {
+		for _, line := range batch {
+			e, err := a.parse(line)
+			if nil != err {
+				s.skipped++
+				continue
+			}
+			r := &http.Request{Method: "GET", Header: http.Header{"User-Agent": {e.userAgent}}}
+			for name, value := range e.headers {
+				r.Header.Set(name, value)
+			}
+			s.add(a.detector.Detect(r))
+		}
+	}
+	return s
+}
+
+func readFile(name string, batches chan<- []string) error {
+	file, err := os.Open(name)
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if nil != err {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if err = readBatches(r, batches); nil != err {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// readBatches sends the lines which are not blank to the workers, by batches.
+func readBatches(r io.Reader, batches chan<- []string) error {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	batch := make([]string, 0, batchSize)
+	for {
+		line, err := readLine(reader)
+		if "" != line {
+			batch = append(batch, line)
+			if batchSize == len(batch) {
+				batches <- batch
+				batch = make([]string, 0, batchSize)
+			}
+		}
+		if io.EOF == err {
+			break
+		}
+		if nil != err {
+			return err
+		}
+	}
+	if 0 != len(batch) {
+		batches <- batch
+	}
+	return nil
+}
+
+// readLine returns the next line, trimmed, or nothing if it is longer than maxLineLength.
+func readLine(reader *bufio.Reader) (string, error) {
+	var line []byte
+	tooLong := false
+	for {
+		chunk, isPrefix, err := reader.ReadLine()
+		if nil != err {
+			return "", err
+		}
+		if len(line)+len(chunk) > maxLineLength {
+			tooLong = true
+		} else {
+			line = append(line, chunk...)
+		}
+		if !isPrefix {
+			break
+		}
+	}
+	if tooLong {
+		// still counted as a skipped line
+		return "-", nil
+	}
+	return strings.TrimSpace(string(line)), nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const accessLog = `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326 "-" "Mozilla/5.0 (iPhone; CPU iPhone OS 6_0_1 like Mac OS X) AppleWebKit/536.26 (KHTML, like Gecko) Version/6.0 Mobile/10A523 Safari/8536.25"
+127.0.0.1 - - [10/Oct/2000:13:55:37 -0700] "GET / HTTP/1.0" 200 2326 "-" "Mozilla/5.0 (iPhone; CPU iPhone OS 6_0_1 like Mac OS X) AppleWebKit/536.26 (KHTML, like Gecko) Version/6.0 Mobile/10A523 Safari/8536.25"
+127.0.0.1 - - [10/Oct/2000:13:55:38 -0700] "GET / HTTP/1.0" 200 2326 "-" "Mozilla/5.0 (Windows NT 6.1; rv:40.0) Gecko/20100101 Firefox/40.0"
+{"http_user_agent": "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"}
+
+not a log line
+`
+
+type report struct {
+	Total   int
+	Skipped int
+	Device  []row
+	OS      []row
+}
+
+func runCommand(t *testing.T, stdin string, args ...string) (string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	if 0 != code {
+		t.Logf("stderr: %s", stderr.String())
+	}
+	return stdout.String(), code
+}
+
+func TestJSONReport(t *testing.T) {
+	stdout, code := runCommand(t, accessLog, "-format", "json", "-workers", "3")
+	var r report
+	if err := json.Unmarshal([]byte(stdout), &r); nil != err || 0 != code {
+		t.Fatalf("Unexpected output %q (%v)", stdout, err)
+	}
+	if 4 != r.Total || 1 != r.Skipped {
+		t.Errorf("Expected 4 requests and 1 skipped line, got %d and %d", r.Total, r.Skipped)
+	}
+	expected := []row{{"Mobile", 2, 50}, {"Bot", 1, 25}, {"Desktop", 1, 25}}
+	if len(expected) != len(r.Device) {
+		t.Fatalf("Expected %v got %v", expected, r.Device)
+	}
+	for i := range expected {
+		if expected[i] != r.Device[i] {
+			t.Errorf("Expected %v got %v", expected[i], r.Device[i])
+		}
+	}
+	if "iOS" != r.OS[0].Value || 2 != r.OS[0].Count {
+		t.Errorf("Unexpected OS breakdown %v", r.OS)
+	}
+}
+
+func TestFilesAndFormats(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "access.log")
+	os.WriteFile(plain, []byte(accessLog), 0o644)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(accessLog))
+	gz.Close()
+	zipped := filepath.Join(dir, "access.log.1.gz")
+	os.WriteFile(zipped, buf.Bytes(), 0o644)
+
+	stdout, code := runCommand(t, "", "-format", "csv", "-log", "combined", plain, zipped)
+	if 0 != code || !strings.Contains(stdout, "device,Mobile,4,66.67") {
+		t.Errorf("Unexpected CSV %q", stdout)
+	}
+	stdout, code = runCommand(t, "", plain)
+	if 0 != code || !strings.Contains(stdout, "DEVICE") || !strings.Contains(stdout, "50.00%") {
+		t.Errorf("Unexpected text %q", stdout)
+	}
+	if _, code = runCommand(t, "", filepath.Join(dir, "missing.log")); 1 != code {
+		t.Errorf("Missing files should be reported, got %d", code)
+	}
+	if _, code = runCommand(t, "", "-log", "xml"); 2 != code {
+		t.Errorf("Unknown log formats should be refused, got %d", code)
+	}
+}
+
+func TestLongLines(t *testing.T) {
+	long := `127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326 "-" "` + strings.Repeat("a", maxLineLength) + `"`
+	stdout, _ := runCommand(t, long+"\n"+accessLog, "-format", "json")
+	var r report
+	json.Unmarshal([]byte(stdout), &r)
+	if 4 != r.Total || 2 != r.Skipped {
+		t.Errorf("Long lines should be skipped, got %d requests and %d skipped", r.Total, r.Skipped)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// entry is what a log line tells about the client.
+type entry struct {
+	userAgent string
+	headers   map[string]string
+}
+
+var errFormat = errors.New("unrecognized log line")
+
+// parser turns a log line into an entry.
+type parser func(line string) (*entry, error)
+
+// The keys JSON logs usually store the User-Agent under, tried in order unless -ua-field is given.
+var userAgentFields = []string{"http_user_agent", "user_agent", "userAgent", "ua", "agent"}
+
+// combinedParser parses the combined log format of Nginx and Apache:
+//
+//	127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326 "http://referer/" "Mozilla/5.0 ..." "extra"
+//
+// Quoted fields logged after the User-Agent hold the given headers, in order.
+func combinedParser(headers []string) parser {
+	return func(line string) (*entry, error) {
+		end := strings.IndexByte(line, ']')
+		if end < 0 {
+			return nil, errFormat
+		}
+		fields, err := quotedFields(line[end+1:])
+		// request, referer and User-Agent
+		if nil != err || len(fields) < 3 {
+			return nil, errFormat
+		}
+		e := &entry{userAgent: dash(fields[2]), headers: make(map[string]string)}
+		for i, name := range headers {
+			if 3+i < len(fields) {
+				if value := dash(fields[3+i]); "" != value {
+					e.headers[name] = value
+				}
+			}
+		}
+		return e, nil
+	}
+}
+
+// jsonParser parses logs written one JSON object per line. The User-Agent is looked for under field, or under
+// the usual keys when field is empty; headers under their name, e.g. X-Wap-Profile, or Nginx variable, e.g. http_x_wap_profile.
+func jsonParser(field string, headers []string) parser {
+	fields := userAgentFields
+	if "" != field {
+		fields = []string{field}
+	}
+	return func(line string) (*entry, error) {
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &object); nil != err {
+			return nil, errFormat
+		}
+		e := &entry{headers: make(map[string]string)}
+		for _, f := range fields {
+			if value, ok := object[f].(string); ok {
+				e.userAgent = dash(value)
+				break
+			}
+		}
+		for _, name := range headers {
+			for _, key := range []string{name, "http_" + strings.ToLower(strings.Replace(name, "-", "_", -1))} {
+				if value, ok := object[key].(string); ok && "" != dash(value) {
+					e.headers[name] = value
+					break
+				}
+			}
+		}
+		return e, nil
+	}
+}
+
+// autoParser picks the JSON parser for lines starting with a brace, and the combined one otherwise.
+func autoParser(combined, json parser) parser {
+	return func(line string) (*entry, error) {
+		if strings.HasPrefix(line, "{") {
+			return json(line)
+		}
+		return combined(line)
+	}
+}
+
+// quotedFields returns the double quoted fields of s, unescaping \" and \\ (Apache) and \xHH (Nginx).
+func quotedFields(s string) ([]string, error) {
+	var fields []string
+	for {
+		start := strings.IndexByte(s, '"')
+		if start < 0 {
+			return fields, nil
+		}
+		var field strings.Builder
+		i := start + 1
+		for ; i < len(s) && '"' != s[i]; i++ {
+			if '\\' != s[i] || i+1 == len(s) {
+				field.WriteByte(s[i])
+				continue
+			}
+			if 'x' == s[i+1] && i+3 < len(s) {
+				if b, err := strconv.ParseUint(s[i+2:i+4], 16, 8); nil == err {
+					field.WriteByte(byte(b))
+					i += 3
+					continue
+				}
+			}
+			i++
+			field.WriteByte(s[i])
+		}
+		if i == len(s) {
+			return nil, errFormat
+		}
+		fields = append(fields, field.String())
+		s = s[i+1:]
+	}
+}
+
+// dash returns the value logged, or nothing when the log says there is none.
+func dash(value string) string {
+	if "-" == value {
+		return ""
+	}
+	return value
+}
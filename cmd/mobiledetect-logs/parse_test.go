@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCombinedParser(t *testing.T) {
+	parse := combinedParser([]string{"X-Wap-Profile", "Accept"})
+	data := []struct {
+		line      string
+		userAgent string
+		headers   map[string]string
+		err       bool
+	}{
+		{`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326 "http://example.com/" "Mozilla/5.0 (iPhone)"`, "Mozilla/5.0 (iPhone)", map[string]string{}, false},
+		{`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326 "-" "Nokia \"quoted\" \x22nginx\x22" "http://wap.example.com/uaprof.xml" "-"`, `Nokia "quoted" "nginx"`, map[string]string{"X-Wap-Profile": "http://wap.example.com/uaprof.xml"}, false},
+		{`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326 "-" "-"`, "", map[string]string{}, false},
+		{`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326`, "", nil, true},
+		{`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700] "GET / HTTP/1.0" 200 2326 "-" "unterminated`, "", nil, true},
+		{`garbage`, "", nil, true},
+	}
+	for _, d := range data {
+		e, err := parse(d.line)
+		if d.err {
+			if nil == err {
+				t.Errorf("%s: expected an error", d.line)
+			}
+			continue
+		}
+		if nil != err || d.userAgent != e.userAgent || !reflect.DeepEqual(d.headers, e.headers) {
+			t.Errorf("%s: expected %q %v got %+v (%v)", d.line, d.userAgent, d.headers, e, err)
+		}
+	}
+}
+
+func TestJSONParser(t *testing.T) {
+	data := []struct {
+		field     string
+		line      string
+		userAgent string
+		headers   map[string]string
+		err       bool
+	}{
+		{"", `{"http_user_agent": "Mozilla/5.0 (iPhone)", "http_x_wap_profile": "http://wap.example.com/uaprof.xml"}`, "Mozilla/5.0 (iPhone)", map[string]string{"X-Wap-Profile": "http://wap.example.com/uaprof.xml"}, false},
+		{"", `{"userAgent": "Mozilla/5.0 (iPad)", "X-Wap-Profile": "-"}`, "Mozilla/5.0 (iPad)", map[string]string{}, false},
+		{"agent", `{"ua": "ignored", "agent": "Opera"}`, "Opera", map[string]string{}, false},
+		{"", `{"status": 200}`, "", map[string]string{}, false},
+		{"", `{"broken`, "", nil, true},
+	}
+	for _, d := range data {
+		e, err := jsonParser(d.field, []string{"X-Wap-Profile"})(d.line)
+		if d.err {
+			if nil == err {
+				t.Errorf("%s: expected an error", d.line)
+			}
+			continue
+		}
+		if nil != err || d.userAgent != e.userAgent || !reflect.DeepEqual(d.headers, e.headers) {
+			t.Errorf("%s: expected %q %v got %+v (%v)", d.line, d.userAgent, d.headers, e, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Shaked/gomobiledetect"
+)
+
+// The breakdowns of the report, in order.
+var dimensions = []string{"device", "os", "browser", "grade"}
+
+// unknown stands for values the detection could not find, such as the OS of desktops.
+const unknown = "unknown"
+
+// stats counts the requests by device, OS, browser and grade.
+type stats struct {
+	total   int
+	skipped int
+	counts  map[string]map[string]int
+}
+
+func newStats() *stats {
+	s := &stats{counts: make(map[string]map[string]int)}
+	for _, dimension := range dimensions {
+		s.counts[dimension] = make(map[string]int)
+	}
+	return s
+}
+
+func (s *stats) add(res *mobiledetect.Result) {
+	s.total++
+	device := res.Device()
+	if res.IsBot() {
+		device = mobiledetect.DEVICE_BOT
+	}
+	s.counts["device"][device]++
+	s.counts["os"][orUnknown(res.OS)]++
+	s.counts["browser"][orUnknown(res.Browser)]++
+	s.counts["grade"][orUnknown(res.Grade)]++
+}
+
+// merge adds the counts of other, gathered by another worker.
+func (s *stats) merge(other *stats) {
+	s.total += other.total
+	s.skipped += other.skipped
+	for dimension, counts := range other.counts {
+		for value, count := range counts {
+			s.counts[dimension][value] += count
+		}
+	}
+}
+
+type row struct {
+	Value   string  `json:"value"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// rows returns the counts of the dimension, the most frequent values first.
+func (s *stats) rows(dimension string) []row {
+	var rows []row
+	for value, count := range s.counts[dimension] {
+		rows = append(rows, row{Value: value, Count: count, Percent: percent(count, s.total)})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Value < rows[j].Value
+	})
+	return rows
+}
+
+func (s *stats) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "requests\t%d\t\n", s.total)
+	fmt.Fprintf(tw, "skipped lines\t%d\t\n", s.skipped)
+	for _, dimension := range dimensions {
+		fmt.Fprintf(tw, "\t\t\n%s\t\t\n", strings.ToUpper(dimension))
+		for _, r := range s.rows(dimension) {
+			fmt.Fprintf(tw, "%s\t%d\t%.2f%%\t\n", r.Value, r.Count, r.Percent)
+		}
+	}
+	return tw.Flush()
+}
+
+func (s *stats) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"dimension", "value", "count", "percent"})
+	for _, dimension := range dimensions {
+		for _, r := range s.rows(dimension) {
+			cw.Write([]string{dimension, r.Value, strconv.Itoa(r.Count), strconv.FormatFloat(r.Percent, 'f', 2, 64)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *stats) writeJSON(w io.Writer) error {
+	report := map[string]interface{}{
+		"total":   s.total,
+		"skipped": s.skipped,
+	}
+	for _, dimension := range dimensions {
+		report[dimension] = s.rows(dimension)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func percent(count, total int) float64 {
+	if 0 == total {
+		return 0
+	}
+	return math.Round(float64(count)*10000/float64(total)) / 100
+}
+
+func orUnknown(value string) string {
+	if "" == value {
+		return unknown
+	}
+	return value
+}
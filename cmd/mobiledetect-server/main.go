@@ -0,0 +1,79 @@
+// Command mobiledetect-server serves the detection over HTTP, for services which are not written in Go.
+//
+//	POST /detect        {"userAgent": "...", "headers": {"X-Wap-Profile": "..."}}
+//	POST /detect/batch  [{"userAgent": "..."}, ...]
+//	GET  /healthz
+//	GET  /version       the upstream version and the fingerprint of the rules
+//
+// Results are JSON objects with the device class, grade, operating system, browser and matching rules.
+// Bodies larger than -max-body bytes are refused, and so are batches of more than -max-batch User-Agents.
+// On SIGINT or SIGTERM the server stops accepting connections and waits for the requests in flight.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stderr, nil))
+}
+
+// run serves until ctx is done. When ready is not nil, the address listened on is sent to it.
+func run(ctx context.Context, args []string, stderr io.Writer, ready chan<- string) int {
+	flags := flag.NewFlagSet("mobiledetect-server", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	maxBody := flags.Int64("max-body", 1<<20, "largest request body accepted, in bytes")
+	maxBatch := flags.Int("max-batch", 1000, "most User-Agents accepted in a batch")
+	cacheSize := flags.Int("cache", 10000, "number of results cached")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 10*time.Second, "how long to wait for requests in flight when stopping")
+	if err := flags.Parse(args); nil != err {
+		return 2
+	}
+
+	srv := &http.Server{
+		Handler:           newServer(config{maxBodyBytes: *maxBody, maxBatch: *maxBatch, cacheSize: *cacheSize}),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+		MaxHeaderBytes:    16 << 10,
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if nil != err {
+		fmt.Fprintf(stderr, "mobiledetect-server: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stderr, "mobiledetect-server: listening on %s\n", listener.Addr())
+	if nil != ready {
+		ready <- listener.Addr().String()
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
+	select {
+	case err = <-served:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer cancel()
+		err = srv.Shutdown(shutdownCtx)
+	}
+	if nil != err && http.ErrServerClosed != err {
+		fmt.Fprintf(stderr, "mobiledetect-server: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Shaked/gomobiledetect"
+)
+
+// detectRequest is the body of POST /detect, and an element of the body of POST /detect/batch.
+type detectRequest struct {
+	UserAgent string            `json:"userAgent"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+type detectResponse struct {
+	UserAgent      string   `json:"userAgent"`
+	Device         string   `json:"device"`
+	Mobile         bool     `json:"mobile"`
+	Tablet         bool     `json:"tablet"`
+	Bot            bool     `json:"bot"`
+	Grade          string   `json:"grade"`
+	PhoneTier      string   `json:"phoneTier,omitempty"`
+	OS             string   `json:"os,omitempty"`
+	OSVersion      string   `json:"osVersion,omitempty"`
+	Browser        string   `json:"browser,omitempty"`
+	BrowserVersion string   `json:"browserVersion,omitempty"`
+	ProxyMode      string   `json:"proxyMode,omitempty"`
+	Carrier        string   `json:"carrier,omitempty"`
+	Keys           []string `json:"keys"`
+}
+
+type versionResponse struct {
+	Upstream    string `json:"upstream"`
+	Fingerprint string `json:"fingerprint"`
+	Rules       int    `json:"rules"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type config struct {
+	maxBodyBytes int64
+	maxBatch     int
+	cacheSize    int
+}
+
+type server struct {
+	config   config
+	detector *mobiledetect.Detector
+	version  versionResponse
+}
+
+func newServer(c config) http.Handler {
+	// the headers are given by the callers on purpose
+	opts := []mobiledetect.Option{mobiledetect.WithTrustedHeaders(mobiledetect.DefaultTrustedHeaders()...)}
+	if c.cacheSize > 0 {
+		opts = append(opts, mobiledetect.WithCache(mobiledetect.NewCache(c.cacheSize)))
+	}
+	s := &server{
+		config:   c,
+		detector: mobiledetect.NewDetector(opts...),
+		version:  versionResponse{Upstream: mobiledetect.UPSTREAM_VERSION, Fingerprint: mobiledetect.RulesFingerprint(), Rules: mobiledetect.RulesCount()},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/detect", s.method("POST", s.detect))
+	mux.HandleFunc("/detect/batch", s.method("POST", s.detectBatch))
+	mux.HandleFunc("/healthz", s.method("GET", s.health))
+	mux.HandleFunc("/version", s.method("GET", s.versionHandler))
+	return mux
+}
+
+// method refuses requests made with another method than the one of the endpoint.
+func (s *server) method(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if method != r.Method && !("GET" == method && "HEAD" == r.Method) {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s only", method))
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (s *server) detect(w http.ResponseWriter, r *http.Request) {
+	var req detectRequest
+	if !s.decode(w, r, &req) {
+		return
+	}
+	writeJSON(w, http.StatusOK, s.response(s.detector.Detect(req.request())))
+}
+
+func (s *server) detectBatch(w http.ResponseWriter, r *http.Request) {
+	var reqs []detectRequest
+	if !s.decode(w, r, &reqs) {
+		return
+	}
+	if len(reqs) > s.config.maxBatch {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("at most %d User-Agents per batch", s.config.maxBatch))
+		return
+	}
+	items := make([]mobiledetect.BatchItem, len(reqs))
+	for i := range reqs {
+		items[i] = reqs[i].item()
+	}
+	results, err := s.detector.DetectBatch(r.Context(), items, 0)
+	if nil != err {
+		// the client is gone
+		return
+	}
+	responses := make([]*detectResponse, len(results))
+	for i, res := range results {
+		responses[i] = s.response(res)
+	}
+	writeJSON(w, http.StatusOK, responses)
+}
+
+func (s *server) health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *server) versionHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.version)
+}
+
+// decode reads the JSON body into v, answering with an error when it is too large or invalid.
+func (s *server) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, s.config.maxBodyBytes+1))
+	if nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	if int64(len(body)) > s.config.maxBodyBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("body larger than %d bytes", s.config.maxBodyBytes))
+		return false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(v); nil != err {
+		writeError(w, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// item turns the request into an item of a batch.
+// request builds the request a single User-Agent is detected from.
+func (req *detectRequest) request() *http.Request {
+	r := &http.Request{Method: "GET", Header: make(http.Header, len(req.Headers)+1)}
+	for name, value := range req.Headers {
+		r.Header.Set(name, value)
+	}
+	r.Header.Set("User-Agent", req.UserAgent)
+	return r
+}
+
+func (req *detectRequest) item() mobiledetect.BatchItem {
+	item := mobiledetect.BatchItem{UserAgent: req.UserAgent, Headers: make(http.Header, len(req.Headers))}
+	for name, value := range req.Headers {
+		item.Headers.Set(name, value)
+	}
+	return item
+}
+
+func (s *server) response(res *mobiledetect.Result) *detectResponse {
+	resp := &detectResponse{
+		UserAgent:      res.UserAgent,
+		Device:         res.Device(),
+		Mobile:         res.Mobile,
+		Tablet:         res.Tablet,
+		Bot:            res.IsBot(),
+		Grade:          res.Grade,
+		PhoneTier:      res.PhoneTier,
+		OS:             res.OS,
+		OSVersion:      res.OSVersion,
+		Browser:        res.Browser,
+		BrowserVersion: res.BrowserVersion,
+		ProxyMode:      res.ProxyMode,
+		Carrier:        res.Carrier,
+		Keys:           make([]string, 0, len(res.Keys)),
+	}
+	for _, key := range res.Keys {
+		name, _ := mobiledetect.KeyName(key)
+		resp.Keys = append(resp.Keys, name)
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shaked/gomobiledetect"
+)
+
+const (
+	iPhoneUserAgent  = "Mozilla/5.0 (iPhone; CPU iPhone OS 6_0_1 like Mac OS X) AppleWebKit/536.26 (KHTML, like Gecko) Version/6.0 Mobile/10A523 Safari/8536.25"
+	iPadUserAgent    = "Mozilla/5.0 (iPad; CPU OS 6_0 like Mac OS X) AppleWebKit/536.26 (KHTML, like Gecko) Version/6.0 Mobile/10A5355d Safari/8536.25"
+	desktopUserAgent = "Mozilla/5.0 (Windows NT 6.1; rv:40.0) Gecko/20100101 Firefox/40.0"
+)
+
+func serve(c config, method, path, body string) (*httptest.ResponseRecorder, []byte) {
+	w := httptest.NewRecorder()
+	newServer(c).ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w, w.Body.Bytes()
+}
+
+var testConfig = config{maxBodyBytes: 1 << 20, maxBatch: 10, cacheSize: 100}
+
+func TestDetect(t *testing.T) {
+	w, body := serve(testConfig, "POST", "/detect", fmt.Sprintf(`{"userAgent": %q}`, iPhoneUserAgent))
+	var resp detectResponse
+	if err := json.Unmarshal(body, &resp); nil != err || http.StatusOK != w.Code {
+		t.Fatalf("Unexpected response %d %s (%v)", w.Code, body, err)
+	}
+	if "application/json" != w.Header().Get("Content-Type") {
+		t.Errorf("Unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	if mobiledetect.DEVICE_MOBILE != resp.Device || !resp.Mobile || resp.Tablet || "iOS" != resp.OS || "A" != resp.Grade {
+		t.Errorf("Unexpected result %+v", resp)
+	}
+	found := false
+	for _, key := range resp.Keys {
+		found = found || "iphone" == key
+	}
+	if !found {
+		t.Errorf("Expected the iPhone rule in %v", resp.Keys)
+	}
+}
+
+func TestDetectHeaders(t *testing.T) {
+	w, body := serve(testConfig, "POST", "/detect", `{"userAgent": "", "headers": {"X-Wap-Profile": "http://wap.samsungmobile.com/uaprof/SGH-I777.xml"}}`)
+	var resp detectResponse
+	if err := json.Unmarshal(body, &resp); nil != err || http.StatusOK != w.Code {
+		t.Fatalf("Unexpected response %d %s (%v)", w.Code, body, err)
+	}
+	if !resp.Mobile {
+		t.Errorf("Expected the headers to make the device mobile, got %+v", resp)
+	}
+}
+
+func TestDetectBatch(t *testing.T) {
+	w, body := serve(testConfig, "POST", "/detect/batch", fmt.Sprintf(`[{"userAgent": %q}, {"userAgent": %q}, {"userAgent": %q}]`, iPhoneUserAgent, iPadUserAgent, desktopUserAgent))
+	var resp []detectResponse
+	if err := json.Unmarshal(body, &resp); nil != err || http.StatusOK != w.Code {
+		t.Fatalf("Unexpected response %d %s (%v)", w.Code, body, err)
+	}
+	expected := []string{mobiledetect.DEVICE_MOBILE, mobiledetect.DEVICE_TABLET, mobiledetect.DEVICE_DESKTOP}
+	if len(expected) != len(resp) {
+		t.Fatalf("Expected %d results got %d", len(expected), len(resp))
+	}
+	for i, device := range expected {
+		if device != resp[i].Device {
+			t.Errorf("Expected %s got %s for %s", device, resp[i].Device, resp[i].UserAgent)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	small := config{maxBodyBytes: 64, maxBatch: 2}
+	w, _ := serve(small, "POST", "/detect", fmt.Sprintf(`{"userAgent": %q}`, iPhoneUserAgent))
+	if http.StatusRequestEntityTooLarge != w.Code {
+		t.Errorf("Expected %d for a large body got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	w, _ = serve(small, "POST", "/detect/batch", `[{}, {}, {}]`)
+	if http.StatusRequestEntityTooLarge != w.Code {
+		t.Errorf("Expected %d for a large batch got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+	w, _ = serve(small, "POST", "/detect/batch", `[{}, {}]`)
+	if http.StatusOK != w.Code {
+		t.Errorf("Expected %d for a batch within the limit got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{"POST", "/detect", `{"userAgent": `, http.StatusBadRequest},
+		{"POST", "/detect", `{"agent": "x"}`, http.StatusBadRequest},
+		{"POST", "/detect/batch", `{"userAgent": "x"}`, http.StatusBadRequest},
+		{"GET", "/detect", ``, http.StatusMethodNotAllowed},
+		{"POST", "/healthz", ``, http.StatusMethodNotAllowed},
+		{"GET", "/unknown", ``, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		w, body := serve(testConfig, test.method, test.path, test.body)
+		if test.code != w.Code {
+			t.Errorf("Expected %d got %d for %s %s %s", test.code, w.Code, test.method, test.path, test.body)
+		}
+		if http.StatusNotFound != test.code && !bytes.Contains(body, []byte(`"error"`)) {
+			t.Errorf("Expected an error for %s %s got %s", test.method, test.path, body)
+		}
+	}
+	w, _ := serve(testConfig, "GET", "/detect", ``)
+	if "POST" != w.Header().Get("Allow") {
+		t.Errorf("Expected Allow: POST got %q", w.Header().Get("Allow"))
+	}
+}
+
+func TestHealthAndVersion(t *testing.T) {
+	w, body := serve(testConfig, "GET", "/healthz", ``)
+	if http.StatusOK != w.Code || !bytes.Contains(body, []byte(`"ok"`)) {
+		t.Errorf("Unexpected health %d %s", w.Code, body)
+	}
+	w, body = serve(testConfig, "GET", "/version", ``)
+	var version versionResponse
+	if err := json.Unmarshal(body, &version); nil != err || http.StatusOK != w.Code {
+		t.Fatalf("Unexpected response %d %s (%v)", w.Code, body, err)
+	}
+	if mobiledetect.UPSTREAM_VERSION != version.Upstream || mobiledetect.RulesFingerprint() != version.Fingerprint || mobiledetect.RulesCount() != version.Rules {
+		t.Errorf("Unexpected version %+v", version)
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ready := make(chan string, 1)
+	done := make(chan int, 1)
+	go func() {
+		done <- run(ctx, []string{"-addr", "127.0.0.1:0"}, io.Discard, ready)
+	}()
+	var addr string
+	select {
+	case addr = <-ready:
+	case code := <-done:
+		t.Fatalf("Server exited with %d", code)
+	}
+	resp, err := http.Get("http://" + addr + "/healthz")
+	if nil != err {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if http.StatusOK != resp.StatusCode {
+		t.Errorf("Expected %d got %d", http.StatusOK, resp.StatusCode)
+	}
+	cancel()
+	select {
+	case code := <-done:
+		if 0 != code {
+			t.Errorf("Expected a clean shutdown got %d", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	if code := run(context.Background(), []string{"-unknown"}, io.Discard, nil); 2 != code {
+		t.Errorf("Expected 2 got %d", code)
+	}
+}
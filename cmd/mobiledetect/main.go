@@ -0,0 +1,296 @@
+// Command mobiledetect classifies User-Agents, given with -ua or read one per line from files or the standard input.
+//
+//	mobiledetect -ua 'Mozilla/5.0 (iPhone; CPU iPhone OS 6_0_1 like Mac OS X) ...'
+//	mobiledetect -format csv access.log.uas > devices.csv
+//	pbpaste | mobiledetect -explain
+//
+// The table lists the device class, grade, operating system, browser, matching rules and their versions for each User-Agent.
+// JSON is written one object per line. With -explain, every matching rule is shown along with the text it matched.
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Shaked/gomobiledetect"
+)
+
+// User-Agents longer than this are cut when reading lines; detection only looks at the first 1024 bytes anyway.
+const maxLineLength = 64 * 1024
+
+type record struct {
+	UserAgent      string            `json:"userAgent"`
+	Device         string            `json:"device"`
+	Grade          string            `json:"grade"`
+	OS             string            `json:"os,omitempty"`
+	OSVersion      string            `json:"osVersion,omitempty"`
+	Browser        string            `json:"browser,omitempty"`
+	BrowserVersion string            `json:"browserVersion,omitempty"`
+	Keys           []string          `json:"keys"`
+	Versions       map[string]string `json:"versions,omitempty"`
+	Explain        []explanation     `json:"explain,omitempty"`
+}
+
+// explanation tells why a rule matched.
+type explanation struct {
+	Key     string `json:"key"`
+	Rule    string `json:"rule"`
+	Matched string `json:"matched"`
+}
+
+type writer interface {
+	write(rec *record) error
+	flush() error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("mobiledetect", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	ua := flags.String("ua", "", "classify this User-Agent instead of reading them")
+	format := flags.String("format", "table", "output format: table, json or csv")
+	explain := flags.Bool("explain", false, "show the rules matching each User-Agent")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mobiledetect [-format table|json|csv] [-explain] [-ua User-Agent | file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); nil != err {
+		return 2
+	}
+
+	var w writer
+	switch *format {
+	case "table":
+		w = newTableWriter(stdout)
+	case "json":
+		w = &jsonWriter{json.NewEncoder(stdout)}
+	case "csv":
+		w = newCSVWriter(stdout, *explain)
+	default:
+		fmt.Fprintf(stderr, "mobiledetect: unknown format %q\n", *format)
+		return 2
+	}
+
+	d := newDetector(*explain)
+	classify := func(userAgent string) error {
+		return w.write(d.detect(userAgent))
+	}
+	var err error
+	if "" != *ua {
+		err = classify(*ua)
+	} else if 0 == flags.NArg() {
+		err = readLines(stdin, classify)
+	} else {
+		for _, name := range flags.Args() {
+			if err = readFile(name, classify); nil != err {
+				break
+			}
+		}
+	}
+	if nil == err {
+		err = w.flush()
+	}
+	if nil != err {
+		fmt.Fprintf(stderr, "mobiledetect: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func readFile(name string, f func(string) error) error {
+	file, err := os.Open(name)
+	if nil != err {
+		return err
+	}
+	defer file.Close()
+	return readLines(file, f)
+}
+
+// readLines calls f for every line which is not blank.
+func readLines(r io.Reader, f func(string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxLineLength)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if "" == line {
+			continue
+		}
+		if err := f(line); nil != err {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+type detector struct {
+	request *http.Request
+	explain bool
+	// compiled rules, for -explain only
+	compiled map[int]*regexp.Regexp
+}
+
+func newDetector(explain bool) *detector {
+	r, _ := http.NewRequest("GET", "/", nil)
+	return &detector{request: r, explain: explain, compiled: make(map[int]*regexp.Regexp)}
+}
+
+func (d *detector) detect(userAgent string) *record {
+	detect := mobiledetect.NewMobileDetect(d.request, nil)
+	detect.SetUserAgent(userAgent)
+	res := detect.Result()
+	rec := &record{
+		UserAgent:      userAgent,
+		Device:         res.Device(),
+		Grade:          res.Grade,
+		OS:             res.OS,
+		OSVersion:      res.OSVersion,
+		Browser:        res.Browser,
+		BrowserVersion: res.BrowserVersion,
+		Keys:           []string{},
+	}
+	for _, key := range res.Keys {
+		name, _ := mobiledetect.KeyName(key)
+		rec.Keys = append(rec.Keys, name)
+		if d.explain {
+			rec.Explain = append(rec.Explain, d.explanation(key, name, res.UserAgent))
+		}
+		// the version patterns are generic, only the ones of the matching rules mean something
+		if version := detect.Version(name); "" != version {
+			if nil == rec.Versions {
+				rec.Versions = make(map[string]string)
+			}
+			rec.Versions[name] = version
+		}
+	}
+	return rec
+}
+
+func (d *detector) explanation(key int, name, userAgent string) explanation {
+	rule, _ := mobiledetect.Rule(key)
+	re, ok := d.compiled[key]
+	if !ok {
+		re = regexp.MustCompile(`(?is)` + rule)
+		d.compiled[key] = re
+	}
+	return explanation{Key: name, Rule: rule, Matched: re.FindString(userAgent)}
+}
+
+// versions formats the versions as name=version pairs, in the order of the keys.
+func (rec *record) versions() string {
+	var pairs []string
+	for _, name := range rec.Keys {
+		if version, ok := rec.Versions[name]; ok {
+			pairs = append(pairs, name+"="+version)
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+type jsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonWriter) write(rec *record) error {
+	return w.encoder.Encode(rec)
+}
+
+func (w *jsonWriter) flush() error {
+	return nil
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	explain bool
+	header  bool
+}
+
+func newCSVWriter(out io.Writer, explain bool) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(out), explain: explain}
+}
+
+func (w *csvWriter) write(rec *record) error {
+	if !w.header {
+		header := []string{"user_agent", "device", "grade", "os", "os_version", "browser", "browser_version", "keys", "versions"}
+		if w.explain {
+			header = append(header, "explain")
+		}
+		w.header = true
+		if err := w.w.Write(header); nil != err {
+			return err
+		}
+	}
+	row := []string{rec.UserAgent, rec.Device, rec.Grade, rec.OS, rec.OSVersion, rec.Browser, rec.BrowserVersion, strings.Join(rec.Keys, " "), rec.versions()}
+	if w.explain {
+		var explain []string
+		for _, e := range rec.Explain {
+			explain = append(explain, fmt.Sprintf("%s:%q", e.Key, e.Matched))
+		}
+		row = append(row, strings.Join(explain, " "))
+	}
+	return w.w.Write(row)
+}
+
+func (w *csvWriter) flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type tableWriter struct {
+	w      *tabwriter.Writer
+	header bool
+}
+
+func newTableWriter(out io.Writer) *tableWriter {
+	return &tableWriter{w: tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)}
+}
+
+func (w *tableWriter) write(rec *record) error {
+	if !w.header {
+		w.header = true
+		fmt.Fprintln(w.w, "DEVICE\tGRADE\tOS\tBROWSER\tKEYS\tVERSIONS\tUSER-AGENT")
+	}
+	_, err := fmt.Fprintf(w.w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", rec.Device, rec.Grade, join(rec.OS, rec.OSVersion),
+		join(rec.Browser, rec.BrowserVersion), orDash(strings.Join(rec.Keys, ",")), orDash(rec.versions()), rec.UserAgent)
+	for _, e := range rec.Explain {
+		// a line without tabs would end the block of aligned columns: explanations go in the last cell, under the User-Agent
+		if _, err = fmt.Fprintf(w.w, "\t\t\t\t\t\t%s matched %q with %s\n", e.Key, e.Matched, shorten(e.Rule, 80)); nil != err {
+			break
+		}
+	}
+	return err
+}
+
+func (w *tableWriter) flush() error {
+	return w.w.Flush()
+}
+
+func join(name, version string) string {
+	return orDash(strings.TrimSpace(name + " " + version))
+}
+
+func orDash(s string) string {
+	if "" == s {
+		return "-"
+	}
+	return s
+}
+
+// shorten cuts long rules, some of them list hundreds of models.
+func shorten(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	iPhoneUserAgent  = `Mozilla/5.0 (iPhone; CPU iPhone OS 6_0_1 like Mac OS X) AppleWebKit/536.26 (KHTML, like Gecko) Version/6.0 Mobile/10A523 Safari/8536.25`
+	desktopUserAgent = `Mozilla/5.0 (Windows NT 6.1; rv:40.0) Gecko/20100101 Firefox/40.0`
+)
+
+func runCommand(t *testing.T, stdin string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestJSON(t *testing.T) {
+	stdout, stderr, code := runCommand(t, iPhoneUserAgent+"\n\n"+desktopUserAgent+"\n", "-format", "json", "-explain")
+	if 0 != code {
+		t.Fatalf("Unexpected exit code %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if 2 != len(lines) {
+		t.Fatalf("Expected a line per User-Agent, got %q", stdout)
+	}
+	var rec record
+	if err := json.Unmarshal([]byte(lines[0]), &rec); nil != err {
+		t.Fatal(err)
+	}
+	if "Mobile" != rec.Device || "A" != rec.Grade || "iOS" != rec.OS || "6_0_1" != rec.Versions["iphone"] {
+		t.Errorf("Unexpected record %+v", rec)
+	}
+	if _, ok := rec.Versions["blackberry"]; ok || len(rec.Keys) < len(rec.Versions) {
+		t.Errorf("Expected the versions of the matching rules only, got %v", rec.Versions)
+	}
+	found := false
+	for _, e := range rec.Explain {
+		if "iphone" == e.Key && "iPhone" == e.Matched {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("The iphone rule should be explained, got %+v", rec.Explain)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &rec); nil != err || "Desktop" != rec.Device || 0 != len(rec.Keys) {
+		t.Errorf("Unexpected record %+v (%v)", rec, err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "uas.txt")
+	os.WriteFile(file, []byte(iPhoneUserAgent+"\n"+desktopUserAgent+"\n"), 0o644)
+	stdout, _, code := runCommand(t, "", "-format", "csv", file)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if 0 != code || 3 != len(lines) {
+		t.Fatalf("Expected a header and two rows, got %d %q", code, stdout)
+	}
+	if !strings.HasPrefix(lines[0], "user_agent,device,grade") || !strings.Contains(lines[1], ",Mobile,A,iOS,6_0_1,Safari,6.0,") {
+		t.Errorf("Unexpected CSV %q", stdout)
+	}
+}
+
+func TestTable(t *testing.T) {
+	stdout, _, code := runCommand(t, iPhoneUserAgent+"\n"+desktopUserAgent+"\n", "-explain")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if 0 != code || len(lines) < 4 {
+		t.Fatalf("Expected a header, rows and explanations, got %q", stdout)
+	}
+	if !strings.HasPrefix(lines[0], "DEVICE") || !strings.HasPrefix(lines[1], "Mobile") || !strings.Contains(stdout, `iphone matched "iPhone"`) {
+		t.Errorf("Unexpected table %q", stdout)
+	}
+	// the rows and their explanations stay aligned on the header
+	column := strings.Index(lines[0], "USER-AGENT")
+	for _, line := range lines[1:] {
+		if column != strings.Index(line, "Mozilla") && column != len(line)-len(strings.TrimLeft(line, " ")) {
+			t.Errorf("Expected %q to be aligned on column %d", line, column)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, _, code := runCommand(t, "", "-format", "xml"); 2 != code {
+		t.Errorf("Unknown formats should be refused, got %d", code)
+	}
+	if _, stderr, code := runCommand(t, "", filepath.Join(t.TempDir(), "missing")); 1 != code || "" == stderr {
+		t.Errorf("Missing files should be reported, got %d %q", code, stderr)
+	}
+}
@@ -0,0 +1,27 @@
+package mobiledetect
+
+import "context"
+
+type contextKey int
+
+const resultContextKey contextKey = 0
+
+// NewContext returns a copy of ctx carrying the detection result.
+func NewContext(ctx context.Context, res *Result) context.Context {
+	return context.WithValue(ctx, resultContextKey, res)
+}
+
+// FromContext returns the detection result carried by ctx, as stored by Handler and HandlerMux on the request context.
+func FromContext(ctx context.Context) (*Result, bool) {
+	res, ok := ctx.Value(resultContextKey).(*Result)
+	return res, ok
+}
+
+// newContext returns a copy of ctx carrying the detection result, and whatever the options derive from it.
+func (o *options) newContext(ctx context.Context, res *Result) context.Context {
+	ctx = NewContext(ctx, res)
+	for _, hook := range o.contextHooks {
+		ctx = hook(ctx, res)
+	}
+	return ctx
+}
@@ -0,0 +1,40 @@
+package mobiledetect
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("Empty context should not carry a result")
+	}
+	res := &Result{Mobile: true}
+	actual, ok := FromContext(NewContext(context.Background(), res))
+	if !ok || actual != res {
+		t.Error("Result was not carried by the context")
+	}
+}
+
+func TestHandlerMuxContext(t *testing.T) {
+	var res *Result
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		res, _ = FromContext(r.Context())
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", `Mozilla/5.0 (iPod touch; CPU iPhone OS 7_0 like Mac OS X) AppleWebKit/537.51.1 (KHTML, like Gecko) Version/7.0 Mobile/11A4449d Safari/9537.53`)
+	HandlerMux(mux, nil).ServeHTTP(httptest.NewRecorder(), r)
+	if nil == res || !res.Mobile || !res.IsKey(IPHONE) || "" != res.Grade {
+		t.Errorf("Unexpected result %+v", res)
+	}
+	HandlerMux(mux, nil, WithDetails(true)).ServeHTTP(httptest.NewRecorder(), r)
+	if nil == res || !res.Mobile || MOBILE_GRADE_A != res.Grade || "iOS" != res.OS {
+		t.Errorf("Unexpected result with details %+v", res)
+	}
+	if _, ok := FromContext(r.Context()); ok {
+		t.Error("The original request should not be modified")
+	}
+}
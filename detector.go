@@ -0,0 +1,21 @@
+package mobiledetect
+
+import "net/http"
+
+// Detector detects the devices of requests outside of Handler and Middleware, e.g. in batch jobs,
+// with the same options every time. It is safe for concurrent use.
+type Detector struct {
+	options *options
+}
+
+// NewDetector creates a Detector with the given options; WithCache is worth it for repetitive traffic.
+// Its results have every detail, unless WithDetails(false) is given.
+func NewDetector(opts ...Option) *Detector {
+	return &Detector{options: newOptions(nil, append([]Option{WithDetails(true)}, opts...))}
+}
+
+// Detect runs the detection for the request, or takes its result from the cache.
+func (d *Detector) Detect(r *http.Request) *Result {
+	_, res := d.options.detect(r)
+	return res
+}
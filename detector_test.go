@@ -0,0 +1,21 @@
+package mobiledetect
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetector(t *testing.T) {
+	cache := NewCache(10)
+	detector := NewDetector(WithCache(cache))
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", iPadUserAgent)
+		if res := detector.Detect(r); !res.Tablet || "iOS" != res.OS {
+			t.Errorf("Unexpected result %+v", res)
+		}
+	}
+	if 1 != cache.Misses() || 2 != cache.Hits() {
+		t.Errorf("Expected 1 miss and 2 hits, got %d and %d", cache.Misses(), cache.Hits())
+	}
+}
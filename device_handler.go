@@ -0,0 +1,82 @@
+package mobiledetect
+
+import "net/http"
+
+// The device classes handled apart by HandlerAdapter, besides DEVICE_MOBILE, DEVICE_TABLET and DEVICE_DESKTOP.
+const (
+	DEVICE_BOT = "Bot"
+	DEVICE_TV  = "TV"
+)
+
+// BotHandler can be implemented by a DeviceHandler to handle crawlers apart, see Handler.
+type BotHandler interface {
+	Bot(w http.ResponseWriter, r *http.Request, m *MobileDetect)
+}
+
+// TVHandler can be implemented by a DeviceHandler to handle smart TVs apart, see Handler.
+type TVHandler interface {
+	TV(w http.ResponseWriter, r *http.Request, m *MobileDetect)
+}
+
+// HandlerAdapter turns plain http.Handlers, by device class, into a DeviceHandler which is also a BotHandler
+// and a TVHandler. Classes without a handler fall back: tablets to the mobile handler, bots and TVs to the handler
+// of the class of their device, and then all of them to Default. Without Default, such requests are not found.
+//
+//	mobiledetect.Handler(&mobiledetect.HandlerAdapter{
+//		Handlers: map[string]http.Handler{mobiledetect.DEVICE_MOBILE: mobile},
+//		Default:  desktop,
+//	}, nil)
+type HandlerAdapter struct {
+	Handlers map[string]http.Handler
+	Default  http.Handler
+}
+
+func (a *HandlerAdapter) Mobile(w http.ResponseWriter, r *http.Request, m *MobileDetect) {
+	a.serve(w, r, DEVICE_MOBILE)
+}
+
+func (a *HandlerAdapter) Tablet(w http.ResponseWriter, r *http.Request, m *MobileDetect) {
+	a.serve(w, r, DEVICE_TABLET, DEVICE_MOBILE)
+}
+
+func (a *HandlerAdapter) Desktop(w http.ResponseWriter, r *http.Request, m *MobileDetect) {
+	a.serve(w, r, DEVICE_DESKTOP)
+}
+
+func (a *HandlerAdapter) Bot(w http.ResponseWriter, r *http.Request, m *MobileDetect) {
+	a.serve(w, r, append([]string{DEVICE_BOT}, deviceClasses(r)...)...)
+}
+
+func (a *HandlerAdapter) TV(w http.ResponseWriter, r *http.Request, m *MobileDetect) {
+	a.serve(w, r, append([]string{DEVICE_TV}, deviceClasses(r)...)...)
+}
+
+// serve hands the request to the handler of the first class having one.
+func (a *HandlerAdapter) serve(w http.ResponseWriter, r *http.Request, classes ...string) {
+	for _, class := range classes {
+		if handler := a.Handlers[class]; nil != handler {
+			handler.ServeHTTP(w, r)
+			return
+		}
+	}
+	if nil != a.Default {
+		a.Default.ServeHTTP(w, r)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// deviceClasses returns the class of the device of the request, followed by its fallbacks.
+func deviceClasses(r *http.Request) []string {
+	res, ok := FromContext(r.Context())
+	if !ok {
+		return []string{DEVICE_DESKTOP}
+	}
+	switch res.Device() {
+	case DEVICE_TABLET:
+		return []string{DEVICE_TABLET, DEVICE_MOBILE}
+	case DEVICE_MOBILE:
+		return []string{DEVICE_MOBILE}
+	}
+	return []string{DEVICE_DESKTOP}
+}
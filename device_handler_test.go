@@ -0,0 +1,67 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// botMethodsStruct is a DeviceHandler which handles bots apart, but not TVs.
+type botMethodsStruct struct {
+	basicMethodsStruct
+}
+
+func (h *botMethodsStruct) Bot(w http.ResponseWriter, r *http.Request, m *MobileDetect) {
+	h.handlerCalled = "bot"
+}
+
+const tvUserAgent = `Opera/9.80 (Linux mips; U; HbbTV/1.1.1 (; Philips; ; ; ; ) CE-HTML/1.0 NETTV/3.2.1; en) Presto/2.6.33 Version/10.70`
+
+func TestHandlerExtensions(t *testing.T) {
+	data := []struct {
+		userAgent, url string
+		expected       string
+	}{
+		{googlebotUserAgent, "/", "bot"},
+		{googlebotSmartphoneUserAgent, "/", "bot"},
+		{googlebotUserAgent, "/?device=tablet", "tablet"},
+		{iPhoneUserAgent, "/", "mobile"},
+		{tvUserAgent, "/", "desktop"},
+	}
+	for _, d := range data {
+		h := &botMethodsStruct{}
+		r := httptest.NewRequest("GET", d.url, nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		Handler(h, nil, WithOverride(overrideConfig)).ServeHTTP(httptest.NewRecorder(), r)
+		if d.expected != h.handlerCalled {
+			t.Errorf("%s %s: expected %s got %s", d.userAgent, d.url, d.expected, h.handlerCalled)
+		}
+	}
+}
+
+func TestHandlerAdapter(t *testing.T) {
+	data := []struct {
+		adapter   *HandlerAdapter
+		userAgent string
+		code      int
+		body      string
+	}{
+		{&HandlerAdapter{Default: respond("default")}, iPadUserAgent, http.StatusOK, "default"},
+		{&HandlerAdapter{}, iPadUserAgent, http.StatusNotFound, ""},
+		{&HandlerAdapter{Handlers: map[string]http.Handler{DEVICE_MOBILE: respond("mobile")}}, iPadUserAgent, http.StatusOK, "mobile"},
+		{&HandlerAdapter{Handlers: map[string]http.Handler{DEVICE_MOBILE: respond("mobile")}, Default: respond("default")}, desktopUserAgent, http.StatusOK, "default"},
+		{&HandlerAdapter{Handlers: map[string]http.Handler{DEVICE_BOT: respond("bot")}}, googlebotUserAgent, http.StatusOK, "bot"},
+		{&HandlerAdapter{Handlers: map[string]http.Handler{DEVICE_MOBILE: respond("mobile")}}, googlebotSmartphoneUserAgent, http.StatusOK, "mobile"},
+		{&HandlerAdapter{Handlers: map[string]http.Handler{DEVICE_TV: respond("tv"), DEVICE_DESKTOP: respond("desktop")}}, tvUserAgent, http.StatusOK, "tv"},
+		{&HandlerAdapter{Handlers: map[string]http.Handler{DEVICE_DESKTOP: respond("desktop")}}, tvUserAgent, http.StatusOK, "desktop"},
+	}
+	for idx, d := range data {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		w := httptest.NewRecorder()
+		Handler(d.adapter, nil).ServeHTTP(w, r)
+		if d.code != w.Code || (http.StatusOK == d.code && d.body != w.Body.String()) {
+			t.Errorf("%d: expected %d %q got %d %q", idx, d.code, d.body, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/Shaked/gomobiledetect"
+)
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	res, _ := mobiledetect.FromContext(r.Context())
+	fmt.Fprintf(w, "%s, grade %s", res.Device(), res.Grade)
+}
+
+func main() {
+	log.Println("Starting local server http://localhost:10001/check (cmd+click to open from terminal)")
+	detect := mobiledetect.Middleware(mobiledetect.WithCache(mobiledetect.NewCache(10000)))
+	http.Handle("/check", detect(http.HandlerFunc(handler)))
+	http.ListenAndServe(":10001", nil)
+}
@@ -0,0 +1,75 @@
+package mobiledetect
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+)
+
+// The headers proxies are known to forward the User-Agent of the device in, tried in this order
+// unless ForwardedUserAgentConfig.Headers says otherwise.
+var defaultForwardedUserAgentHeaders = []string{
+	"X-Operamini-Phone-Ua",
+	"Device-Stock-Ua",
+	"X-Original-User-Agent",
+}
+
+// ForwardedUserAgentConfig tells where to find the User-Agent of the device when a proxy, such as
+// Opera Mini, a transcoding proxy or a CDN, replaced it with its own.
+type ForwardedUserAgentConfig struct {
+	// Headers holding the User-Agent of the device, the first one present being used:
+	// X-OperaMini-Phone-UA, Device-Stock-UA and X-Original-User-Agent when empty.
+	Headers []string
+	// Proxies are the networks the proxies connect from, matched against the RemoteAddr of the requests.
+	// When empty, the headers are trusted whoever sends them: only do so when every client goes through
+	// proxies removing any copy sent by the clients themselves.
+	Proxies []netip.Prefix
+}
+
+// WithForwardedUserAgent detects devices from the User-Agent forwarded by trusted proxies, rather than from
+// the User-Agent header, which then holds the one of the proxy. Result.UserAgent is the forwarded User-Agent,
+// Result.ProxyUserAgent the one of the proxy and Result.ForwardedBy the header the device was found in.
+func WithForwardedUserAgent(config ForwardedUserAgentConfig) Option {
+	return func(o *options) {
+		if 0 == len(config.Headers) {
+			config.Headers = defaultForwardedUserAgentHeaders
+		}
+		o.forwarded = &config
+	}
+}
+
+// userAgent returns the User-Agent of the device forwarded with the request, and the header holding it,
+// or nothing if the request did not come through a trusted proxy.
+func (c *ForwardedUserAgentConfig) userAgent(r *http.Request) (string, string) {
+	if !c.trusted(r) {
+		return "", ""
+	}
+	for _, name := range c.Headers {
+		if value := r.Header.Get(name); "" != value {
+			return value, http.CanonicalHeaderKey(name)
+		}
+	}
+	return "", ""
+}
+
+// trusted tells whether the request comes from one of the proxies.
+func (c *ForwardedUserAgentConfig) trusted(r *http.Request) bool {
+	if 0 == len(c.Proxies) {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if nil != err {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if nil != err {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range c.Proxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package mobiledetect
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+const operaMiniUserAgent = `Opera/9.80 (J2ME/MIDP; Opera Mini/9.80 (S60; SymbOS; Opera Mobi/23.348; U; en) Presto/2.5.25 Version/10.54`
+
+func TestForwardedUserAgent(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+	data := []struct {
+		config     ForwardedUserAgentConfig
+		remoteAddr string
+		header     string
+		value      string
+		userAgent  string
+		by         string
+	}{
+		{ForwardedUserAgentConfig{}, "192.0.2.1:1234", "X-OperaMini-Phone-UA", iPhoneUserAgent, iPhoneUserAgent, "X-Operamini-Phone-Ua"},
+		{ForwardedUserAgentConfig{}, "192.0.2.1:1234", "Device-Stock-UA", iPadUserAgent, iPadUserAgent, "Device-Stock-Ua"},
+		{ForwardedUserAgentConfig{}, "192.0.2.1:1234", "X-Original-User-Agent", iPhoneUserAgent, iPhoneUserAgent, "X-Original-User-Agent"},
+		{ForwardedUserAgentConfig{}, "192.0.2.1:1234", "X-Other", iPhoneUserAgent, desktopUserAgent, ""},
+		{ForwardedUserAgentConfig{Headers: []string{"X-Other"}}, "192.0.2.1:1234", "X-Other", iPhoneUserAgent, iPhoneUserAgent, "X-Other"},
+		{ForwardedUserAgentConfig{Proxies: proxies}, "10.1.2.3:1234", "X-Original-User-Agent", iPhoneUserAgent, iPhoneUserAgent, "X-Original-User-Agent"},
+		{ForwardedUserAgentConfig{Proxies: proxies}, "[2001:db8::1]:1234", "X-Original-User-Agent", iPhoneUserAgent, iPhoneUserAgent, "X-Original-User-Agent"},
+		{ForwardedUserAgentConfig{Proxies: proxies}, "[::ffff:10.1.2.3]:1234", "X-Original-User-Agent", iPhoneUserAgent, iPhoneUserAgent, "X-Original-User-Agent"},
+		{ForwardedUserAgentConfig{Proxies: proxies}, "192.0.2.1:1234", "X-Original-User-Agent", iPhoneUserAgent, desktopUserAgent, ""},
+		{ForwardedUserAgentConfig{Proxies: proxies}, "garbage", "X-Original-User-Agent", iPhoneUserAgent, desktopUserAgent, ""},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = d.remoteAddr
+		r.Header.Set("User-Agent", desktopUserAgent)
+		r.Header.Set(d.header, d.value)
+		res := NewDetector(WithForwardedUserAgent(d.config)).Detect(r)
+		if d.userAgent != res.UserAgent || d.by != res.ForwardedBy {
+			t.Errorf("Expected %q from %q got %q from %q for %s from %s", d.userAgent, d.by, res.UserAgent, res.ForwardedBy, d.header, d.remoteAddr)
+		}
+		if proxy := map[bool]string{true: desktopUserAgent}["" != d.by]; proxy != res.ProxyUserAgent {
+			t.Errorf("Expected the proxy %q got %q", proxy, res.ProxyUserAgent)
+		}
+	}
+}
+
+func TestForwardedUserAgentDetection(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", operaMiniUserAgent)
+	r.Header.Set("X-OperaMini-Phone-UA", iPadUserAgent)
+	cache := NewCache(10)
+	detector := NewDetector(WithForwardedUserAgent(ForwardedUserAgentConfig{}), WithCache(cache))
+	if res := detector.Detect(r); !res.Tablet || "iOS" != res.OS || operaMiniUserAgent != res.ProxyUserAgent {
+		t.Errorf("Expected the iPad behind Opera Mini got %+v", res)
+	}
+	// the same device behind another proxy is another result
+	r.Header.Set("User-Agent", desktopUserAgent)
+	if res := detector.Detect(r); desktopUserAgent != res.ProxyUserAgent {
+		t.Errorf("Expected the proxy %q got %q", desktopUserAgent, res.ProxyUserAgent)
+	}
+	if 2 != cache.Misses() {
+		t.Errorf("Expected 2 misses got %d", cache.Misses())
+	}
+	// without the option, the header is only a mobile hint, when trusted
+	if res := NewDetector(WithTrustedHeaders(DefaultTrustedHeaders()...)).Detect(r); desktopUserAgent != res.UserAgent || "" != res.ForwardedBy || !res.Mobile {
+		t.Errorf("Unexpected result %+v", res)
+	}
+}
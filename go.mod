@@ -0,0 +1,3 @@
+module github.com/Shaked/gomobiledetect
+
+go 1.18
@@ -5,8 +5,7 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
-
-	"github.com/gorilla/context"
+	"sync"
 )
 
 const (
@@ -18,10 +17,14 @@ const (
 	MOBILE_GRADE_C = "C"
 )
 
-// Vars returns the route variables for the current request, if any.
+// defaultRules are shared by every MobileDetect created without rules, so their regular expressions are compiled only once.
+var defaultRules = NewRules()
+
+// Device returns the class of the device (Mobile, Tablet or Desktop) found by HandlerMux for the request, if any.
+// FromContext gives access to the full result.
 func Device(r *http.Request) string {
-	if rv := context.Get(r, "Device"); rv != nil {
-		return rv.(string)
+	if res, ok := FromContext(r.Context()); ok {
+		return res.Device()
 	}
 	return ""
 }
@@ -32,12 +35,23 @@ type DeviceHandler interface {
 	Desktop(w http.ResponseWriter, r *http.Request, m *MobileDetect)
 }
 
-func Handler(h DeviceHandler, rules *rules) http.Handler {
+// Handler calls the method of h matching the device of the request. Bots and TVs go to the Bot and TV methods
+// of h when it implements BotHandler or TVHandler, and to the method of their device class otherwise.
+func Handler(h DeviceHandler, rules *rules, opts ...Option) http.Handler {
+	o := newOptions(rules, opts)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		m := NewMobileDetect(r, rules)
-		if m.IsTablet() {
+		m, res := o.detect(r)
+		res = o.applyOverride(w, r, res)
+		o.setResponseHeaders(w, res)
+		r = r.WithContext(o.newContext(r.Context(), res))
+		o.setBucketHeader(r, res)
+		if bh, ok := h.(BotHandler); ok && !res.Overridden && res.IsBot() {
+			bh.Bot(w, r, m)
+		} else if th, ok := h.(TVHandler); ok && !res.Overridden && res.IsKey(TV) {
+			th.TV(w, r, m)
+		} else if res.Tablet {
 			h.Tablet(w, r, m)
-		} else if m.IsMobile() {
+		} else if res.Mobile {
 			h.Mobile(w, r, m)
 		} else {
 			h.Desktop(w, r, m)
@@ -45,18 +59,10 @@ func Handler(h DeviceHandler, rules *rules) http.Handler {
 	})
 }
 
-func HandlerMux(s *http.ServeMux, rules *rules) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		m := NewMobileDetect(r, rules)
-		if m.IsTablet() {
-			context.Set(r, "Device", "Tablet")
-		} else if m.IsMobile() {
-			context.Set(r, "Device", "Mobile")
-		} else {
-			context.Set(r, "Device", "Desktop")
-		}
-		s.ServeHTTP(w, r)
-	})
+// HandlerMux stores the detection result in the request context, see Device and FromContext, before handing the request to s.
+// Middleware does the same for any http.Handler.
+func HandlerMux(s *http.ServeMux, rules *rules, opts ...Option) http.Handler {
+	return newOptions(rules, opts).middleware(s)
 }
 
 // MobileDetect holds the structure to figure out a browser from a UserAgent string and methods necessary to make it happen
@@ -66,25 +72,51 @@ type MobileDetect struct {
 	httpHeaders          map[string]string
 	mobileDetectionRules map[string]string
 	compiledRegexRules   map[string]*regexp.Regexp
+	options              *options
+	// the User-Agent of the proxy and the header the one of the device came from, see WithForwardedUserAgent
+	proxyUserAgent string
+	forwardedBy    string
+	memo           memo
 	*properties
 }
 
-// NewMobileDetect creates the MobileDetect object
-func NewMobileDetect(r *http.Request, rules *rules) *MobileDetect {
-	if nil == rules {
-		rules = NewRules()
-	}
+// memo keeps what was already worked out about the User-Agent and the headers,
+// so that asking the same question twice doesn't run the regular expressions again.
+type memo struct {
+	matched          matchSet
+	versions         map[int]string
+	patterns         map[string]bool
+	headersChecked   bool
+	headersForMobile bool
+	grade            string
+}
+
+// NewMobileDetect creates the MobileDetect object. It only looks at the User-Agent of the request unless headers
+// are trusted with WithTrustedHeaders, e.g. WithTrustedHeaders(DefaultTrustedHeaders()...).
+func NewMobileDetect(r *http.Request, rules *rules, opts ...Option) *MobileDetect {
+	return newOptions(rules, opts).newMobileDetect(r)
+}
+
+func (o *options) newMobileDetect(r *http.Request) *MobileDetect {
 	md := &MobileDetect{
-		rules:              rules,
-		userAgent:          r.UserAgent(),
-		httpHeaders:        getHttpHeaders(r),
-		compiledRegexRules: make(map[string]*regexp.Regexp, len(rules.mobileDetectionRules())),
-		properties:         newProperties(),
+		rules:              o.rules,
+		userAgent:          truncate(r.UserAgent(), o.maxUserAgentLength),
+		httpHeaders:        getHttpHeaders(r, o.trustedHeaders),
+		compiledRegexRules: make(map[string]*regexp.Regexp, len(o.rules.mobileDetectionRules())),
+		options:            o,
+		properties:         defaultProperties(),
+	}
+	if nil != o.forwarded {
+		if userAgent, header := o.forwarded.userAgent(r); "" != userAgent {
+			md.proxyUserAgent = md.userAgent
+			md.forwardedBy = header
+			md.userAgent = truncate(userAgent, o.maxUserAgentLength)
+		}
 	}
 	return md
 }
 
-func getHttpHeaders(r *http.Request) map[string]string {
+func getHttpHeaders(r *http.Request, trustedHeaders []string) map[string]string {
 	httpHeaders := map[string]string{
 		"SERVER_SOFTWARE":  r.Header.Get("SERVER_SOFTWARE"),
 		"REQUEST_METHOD":   r.Method,
@@ -103,24 +135,36 @@ func getHttpHeaders(r *http.Request) map[string]string {
 		"REMOTE_ADDR":      r.RemoteAddr,
 		"REQUEST_TIME":     r.Header.Get("REQUEST_TIME"),
 	}
+	// trusted headers are also made available the way CheckHttpHeadersForMobile expects them, e.g. HTTP_ACCEPT
+	for _, name := range trustedHeaders {
+		if values, ok := r.Header[http.CanonicalHeaderKey(name)]; ok && 0 != len(values) {
+			httpHeaders[cgiHeaderName(name)] = values[0]
+		}
+	}
 
 	return httpHeaders
 }
 
 func (md *MobileDetect) PreCompileRegexRules() *MobileDetect {
-	for _, ruleValue := range md.rules.mobileDetectionRules() {
-		md.match(ruleValue)
+	m := md.rules.compiled()
+	for key, ruleValue := range md.rules.mobileDetectionRules() {
+		if nil != m.regexes[key] {
+			md.compiledRegexRules[`(?is)`+ruleValue] = m.regexes[key]
+		}
 	}
 	return md
 }
 
+// SetUserAgent replaces the User-Agent, truncated as explained in WithMaxUserAgentLength.
 func (md *MobileDetect) SetUserAgent(userAgent string) *MobileDetect {
-	md.userAgent = userAgent
+	md.userAgent = truncate(userAgent, md.options.maxUserAgentLength)
+	md.memo = memo{}
 	return md
 }
 
 func (md *MobileDetect) SetHttpHeaders(httpHeaders map[string]string) *MobileDetect {
 	md.httpHeaders = httpHeaders
+	md.memo = memo{}
 	return md
 }
 
@@ -134,12 +178,7 @@ func (md *MobileDetect) IsMobile() bool {
 
 // IsMobile is a specific case to detect only mobile browsers on tablets. Do not overlap with IsMobile
 func (md *MobileDetect) IsTablet() bool {
-	for _, ruleValue := range md.rules.tabletDevices {
-		if md.match(ruleValue) {
-			return true
-		}
-	}
-	return false
+	return md.rules.compiled().isTablet(md.matches())
 }
 
 // Is compared the detected browser with a "rule" from the existing rules list
@@ -166,19 +205,27 @@ func (md *MobileDetect) Is(key interface{}) bool {
 
 // VersionFloat does the same as Version, but returns a float number good for version comparison
 func (md *MobileDetect) VersionFloatKey(propertyVal int) float64 {
-	return md.properties.versionFloat(propertyVal, md.userAgent)
+	return versionFloat(md.VersionKey(propertyVal))
 }
 
 // Version detects the browser version returning as string
 func (md *MobileDetect) VersionKey(propertyVal int) string {
-	return md.properties.version(propertyVal, md.userAgent)
+	if version, ok := md.memo.versions[propertyVal]; ok {
+		return version
+	}
+	version := md.properties.version(propertyVal, md.userAgent)
+	if nil == md.memo.versions {
+		md.memo.versions = make(map[int]string)
+	}
+	md.memo.versions[propertyVal] = version
+	return version
 }
 
 // It is recommended to use VersionFloatKey instead
 func (md *MobileDetect) VersionFloat(propertyName interface{}) float64 {
 	switch propertyName.(type) {
 	case string:
-		return md.properties.versionFloatName(propertyName.(string), md.userAgent)
+		return md.VersionFloatKey(md.properties.nameToKey(propertyName.(string)))
 	case int:
 		return md.VersionFloatKey(propertyName.(int))
 	}
@@ -189,7 +236,7 @@ func (md *MobileDetect) VersionFloat(propertyName interface{}) float64 {
 func (md *MobileDetect) Version(propertyName interface{}) string {
 	switch propertyName.(type) {
 	case string:
-		return md.properties.versionByName(propertyName.(string), md.userAgent)
+		return md.VersionKey(md.properties.nameToKey(propertyName.(string)))
 	case int:
 		return md.VersionKey(propertyName.(int))
 	}
@@ -199,29 +246,21 @@ func (md *MobileDetect) Version(propertyName interface{}) string {
 //Search for a certain key in the rules array.
 //If the key is found the try to match the corresponding regex agains the User-Agent.
 func (md *MobileDetect) matchUAAgainstKey(key int) bool {
-	ret := false
-	rules := md.rules.mobileDetectionRules()
-	for ruleKey, ruleValue := range rules {
-		if key == ruleKey {
-			ret = md.match(ruleValue)
-			break
-		}
-	}
-
-	return ret
+	return md.matches().has(key)
 }
 
 //Find a detection rule that matches the current User-agent.
 func (md *MobileDetect) matchDetectionRulesAgainstUA() bool {
-	for _, ruleValue := range md.rules.mobileDetectionRules() {
-		if "" != ruleValue {
-			if md.match(ruleValue) {
-				return true
-			}
-		}
-	}
+	return md.rules.compiled().isMobile(md.matches())
+}
 
-	return false
+// matches runs all the rules against the User-Agent in a single pass and keeps the outcome,
+// so IsMobile, IsTablet and Is don't need to go over the rules again.
+func (md *MobileDetect) matches() matchSet {
+	if nil == md.memo.matched {
+		md.memo.matched = md.rules.match(md.userAgent)
+	}
+	return md.memo.matched
 }
 
 // Some detection rules are relative (not standard),because of the diversity of devices, vendors and
@@ -229,23 +268,56 @@ func (md *MobileDetect) matchDetectionRulesAgainstUA() bool {
 // This method will be used to check custom regexes against the User-Agent string.
 // @todo: search in the HTTP headers too.
 func (md *MobileDetect) match(ruleValue string) bool {
+	if ret, ok := md.memo.patterns[ruleValue]; ok {
+		return ret
+	}
 	//Escape the special character which is the delimiter
 	//rule = strings.Replace(rule, `\`, `\/`, -1)
-	ruleValue = `(?is)` + ruleValue
-	var re *regexp.Regexp
-	re = md.compiledRegexRules[ruleValue]
+	pattern := `(?is)` + ruleValue
+	re := md.compiledRegexRules[pattern]
 	if nil == re {
-		md.compiledRegexRules[ruleValue] = regexp.MustCompile(ruleValue)
+		re = compiledPattern(pattern)
+		md.compiledRegexRules[pattern] = re
 	}
-	re = md.compiledRegexRules[ruleValue]
 	ret := re.MatchString(md.userAgent)
+	if nil == md.memo.patterns {
+		md.memo.patterns = make(map[string]bool)
+	}
+	md.memo.patterns[ruleValue] = ret
 	return ret
 }
 
+// Patterns used outside of the rules, such as the MobileGrade ones, are compiled once for all MobileDetect objects.
+var compiledPatterns = struct {
+	sync.RWMutex
+	m map[string]*regexp.Regexp
+}{m: make(map[string]*regexp.Regexp)}
+
+func compiledPattern(pattern string) *regexp.Regexp {
+	compiledPatterns.RLock()
+	re := compiledPatterns.m[pattern]
+	compiledPatterns.RUnlock()
+	if nil == re {
+		re = regexp.MustCompile(pattern)
+		compiledPatterns.Lock()
+		compiledPatterns.m[pattern] = re
+		compiledPatterns.Unlock()
+	}
+	return re
+}
+
 // CheckHttpHeadersForMobile looks for mobile rules to confirm if the browser is a mobile browser
 func (md *MobileDetect) CheckHttpHeadersForMobile() bool {
+	if !md.memo.headersChecked {
+		md.memo.headersForMobile = md.checkHttpHeadersForMobile()
+		md.memo.headersChecked = true
+	}
+	return md.memo.headersForMobile
+}
+
+func (md *MobileDetect) checkHttpHeadersForMobile() bool {
 	for _, mobileHeader := range md.mobileHeaders() {
-		if headerString, ok := md.httpHeaders[mobileHeader]; ok {
+		if headerString, ok := md.httpHeader(mobileHeader); ok {
 			mobileHeaderMatches := md.mobileHeaderMatches()
 			if matches, ok := mobileHeaderMatches[mobileHeader]; ok {
 				for _, match := range matches {
@@ -253,7 +325,8 @@ func (md *MobileDetect) CheckHttpHeadersForMobile() bool {
 						return true
 					}
 				}
-				return false
+				// every browser sends Accept, the other headers must still be looked at
+				continue
 			} else {
 				return true
 			}
@@ -262,7 +335,18 @@ func (md *MobileDetect) CheckHttpHeadersForMobile() bool {
 	return false
 }
 
+// httpHeader returns the value of a header, truncated as explained in WithMaxHeaderBytes.
+func (md *MobileDetect) httpHeader(name string) (string, bool) {
+	headerString, ok := md.httpHeaders[name]
+	return truncate(headerString, md.options.maxHeaderBytes), ok
+}
+
 func (md *MobileDetect) mobileHeaders() []string {
+	return mobileHeaderNames()
+}
+
+// mobileHeaderNames returns the headers CheckHttpHeadersForMobile looks at.
+func mobileHeaderNames() []string {
 	return []string{
 		"HTTP_ACCEPT",
 		"HTTP_X_WAP_PROFILE",
@@ -302,6 +386,13 @@ func (md *MobileDetect) mobileHeaderMatches() map[string][]string {
 
 // MobileGrade returns a graduation similar to jQuery's Graded Browse Support
 func (md *MobileDetect) MobileGrade() string {
+	if "" == md.memo.grade {
+		md.memo.grade = md.mobileGrade()
+	}
+	return md.memo.grade
+}
+
+func (md *MobileDetect) mobileGrade() string {
 	isMobile := md.IsMobile()
 
 	if md.isMobileGradeA(isMobile) {
@@ -0,0 +1,127 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// The fuzz targets truncate inputs to this many bytes, which keeps every run and the minimization of inputs
+// short; how fast detection is on inputs as long as the default limits is left to the benchmarks.
+const fuzzMaxLength = 256
+
+func fuzzSeeds(f *testing.F) {
+	for _, test := range uaListTests[:50] {
+		f.Add(test.userAgent, "")
+	}
+	f.Add("", "")
+	// just past the limit: only the truncated part is ever looked at
+	f.Add(strings.Repeat("Android ", 40), "text/vnd.wap.wml")
+	f.Add(strings.Repeat("iPhone.*CPU", 30)+"\xff\xfe", strings.Repeat("a", 2*fuzzMaxLength))
+	f.Add("Mozilla/5.0 (Linux; Android Kſ; ("+strings.Repeat("(", 400), "application/x-obml2d")
+}
+
+func fuzzDetect(t *testing.T, userAgent, accept string) *MobileDetect {
+	if len(userAgent) > 2*fuzzMaxLength || len(accept) > 2*fuzzMaxLength {
+		t.Skip("longer inputs are truncated the same way")
+	}
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", userAgent)
+	detect := NewMobileDetect(r, nil, WithMaxUserAgentLength(fuzzMaxLength), WithMaxHeaderBytes(fuzzMaxLength))
+	detect.SetHttpHeaders(map[string]string{"HTTP_ACCEPT": accept})
+	// whatever the client sends, the rules only run on bounded inputs
+	if len(detect.userAgent) > fuzzMaxLength {
+		t.Errorf("User-Agent was not truncated: %d bytes", len(detect.userAgent))
+	}
+	if header, _ := detect.httpHeader("HTTP_ACCEPT"); len(header) > fuzzMaxLength {
+		t.Errorf("Accept was not truncated: %d bytes", len(header))
+	}
+	return detect
+}
+
+func FuzzIsMobile(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, userAgent, accept string) {
+		detect := fuzzDetect(t, userAgent, accept)
+		detect.IsMobile()
+		detect.MobileGrade()
+	})
+}
+
+func FuzzIsTablet(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, userAgent, accept string) {
+		detect := fuzzDetect(t, userAgent, accept)
+		if detect.IsTablet() && !detect.IsMobile() {
+			t.Error("A tablet should be mobile as well")
+		}
+	})
+}
+
+func FuzzVersion(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, userAgent, accept string) {
+		detect := fuzzDetect(t, userAgent, accept)
+		for name, key := range propertiesNameToVal {
+			if detect.Version(name) != detect.VersionKey(key) {
+				t.Errorf("Version(%s) and VersionKey(%d) differ", name, key)
+			}
+			detect.VersionFloatKey(key)
+		}
+		detect.VersionKey(-1)
+		detect.VersionKey(len(props))
+	})
+}
+
+func TestMaxUserAgentLength(t *testing.T) {
+	userAgent := `Mozilla/5.0 (Linux; U; Android 4.0.3; ko-kr; LG-L160L Build/IML74K) AppleWebKit/534.30 (KHTML, like Gecko) Version/4.0 Mobile Safari/534.30`
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", strings.Repeat(" ", DEFAULT_MAX_USER_AGENT_LENGTH)+userAgent)
+	if NewMobileDetect(r, nil).IsMobile() {
+		t.Error("Tokens past the default limit should not be seen")
+	}
+	if !NewMobileDetect(r, nil, WithMaxUserAgentLength(0)).IsMobile() {
+		t.Error("Tokens should be seen without a limit")
+	}
+
+	detect := NewMobileDetect(r, nil, WithMaxUserAgentLength(20))
+	detect.SetUserAgent(userAgent)
+	if "Mozilla/5.0 (Linux; " != detect.userAgent {
+		t.Errorf("SetUserAgent should truncate to 20 bytes, got %q", detect.userAgent)
+	}
+}
+
+func TestMaxHeaderBytes(t *testing.T) {
+	accept := strings.Repeat("text/html, ", 10) + "text/vnd.wap.wml"
+	detect := NewMobileDetect(httpRequest, nil, WithMaxHeaderBytes(50))
+	detect.SetHttpHeaders(map[string]string{"HTTP_ACCEPT": accept})
+	if detect.CheckHttpHeadersForMobile() {
+		t.Error("Header values past the limit should not be inspected")
+	}
+	detect = NewMobileDetect(httpRequest, nil)
+	detect.SetHttpHeaders(map[string]string{"HTTP_ACCEPT": accept})
+	if !detect.CheckHttpHeadersForMobile() {
+		t.Error("Header value should be inspected with the default limit")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	data := []struct {
+		s        string
+		n        int
+		expected string
+	}{
+		{"iPhone", 3, "iPh"},
+		{"iPhone", 10, "iPhone"},
+		{"iPhone", 0, "iPhone"},
+		{"aKb", 2, "a"},
+		{"aKb", 4, "aK"},
+	}
+	for _, d := range data {
+		actual := truncate(d.s, d.n)
+		if d.expected != actual || !utf8.ValidString(actual) {
+			t.Errorf("truncate(%q, %d) expected %q got %q", d.s, d.n, d.expected, actual)
+		}
+	}
+}
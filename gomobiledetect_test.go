@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -388,11 +389,36 @@ func TestVersionExtraction(t *testing.T) {
 
 		detectedVersionFloat := detect.VersionFloat(property)
 		if floatVersion != detectedVersionFloat {
-			t.Errorf("Float version %d is mismatched (detectedVersion %d, property %s)", floatVersion, detectedVersionFloat, property)
+			t.Errorf("Float version %f is mismatched (detectedVersion %f, property %v)", floatVersion, detectedVersionFloat, property)
 		}
 	}
 }
 
+func TestMemoIsReset(t *testing.T) {
+	detect := NewMobileDetect(httpRequest, nil)
+	detect.SetUserAgent(`Mozilla/5.0 (iPhone; CPU iPhone OS 6_0_1 like Mac OS X) AppleWebKit/536.26 (KHTML, like Gecko) Version/6.0 Mobile/10A523 Safari/8536.25`)
+	if !detect.IsMobile() || !detect.Is("iphone") || "6_0_1" != detect.Version("iPhone") || MOBILE_GRADE_A != detect.MobileGrade() {
+		t.Error("iPhone detection failed")
+	}
+	if nil == detect.memo.matched || 0 == len(detect.memo.versions) || "" == detect.memo.grade {
+		t.Error("Results were not memoized")
+	}
+
+	detect.SetUserAgent(`Mozilla/5.0 (Windows NT 6.1; rv:40.0) Gecko/20100101 Firefox/40.0`)
+	if detect.IsMobile() || detect.Is("iphone") || "" != detect.Version("iPhone") || MOBILE_GRADE_C != detect.MobileGrade() {
+		t.Error("Results of the previous User-Agent were kept after SetUserAgent")
+	}
+
+	detect.SetHttpHeaders(map[string]string{`HTTP_X_WAP_PROFILE`: `http://nds.nokia.com/uaprof/N6230r200.xml`})
+	if !detect.CheckHttpHeadersForMobile() || !detect.IsMobile() {
+		t.Error("Mobile headers were not detected")
+	}
+	detect.SetHttpHeaders(map[string]string{})
+	if detect.CheckHttpHeadersForMobile() || detect.IsMobile() {
+		t.Error("Results of the previous headers were kept after SetHttpHeaders")
+	}
+}
+
 func TestPreCompileRegexRules(t *testing.T) {
 	detect := NewMobileDetect(httpRequest, nil)
 	detect.PreCompileRegexRules()
@@ -403,6 +429,36 @@ func TestPreCompileRegexRules(t *testing.T) {
 	}
 }
 
+func TestNewMobileDetectHeaders(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", desktopUserAgent)
+	r.Header.Set("Accept", "text/vnd.wap.wml")
+	if NewMobileDetect(r, nil).IsMobile() {
+		t.Error("Headers should only be looked at when trusted")
+	}
+	if !NewMobileDetect(r, nil, WithTrustedHeaders(DefaultTrustedHeaders()...)).IsMobile() {
+		t.Error("A trusted WAP Accept header should make the request mobile")
+	}
+}
+
+func TestHandlerHeaders(t *testing.T) {
+	for name, value := range map[string]string{"Profile": "x", "UA-CPU": "ARM"} {
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", desktopUserAgent)
+		r.Header.Set(name, value)
+		deviceHandler := &basicMethodsStruct{}
+		Handler(deviceHandler, nil).ServeHTTP(httptest.NewRecorder(), r)
+		if "desktop" != deviceHandler.handlerCalled {
+			t.Errorf("%s should only be looked at when trusted, got %s", name, deviceHandler.handlerCalled)
+		}
+		deviceHandler = &basicMethodsStruct{}
+		Handler(deviceHandler, nil, WithTrustedHeaders(DefaultTrustedHeaders()...)).ServeHTTP(httptest.NewRecorder(), r)
+		if "mobile" != deviceHandler.handlerCalled {
+			t.Errorf("A trusted %s should make the request mobile, got %s", name, deviceHandler.handlerCalled)
+		}
+	}
+}
+
 func TestHandler(t *testing.T) {
 	expectedResults := map[string]string{
 		"mobile":  `Mozilla/5.0 (iPod touch; CPU iPhone OS 7_0 like Mac OS X) AppleWebKit/537.51.1 (KHTML, like Gecko) Version/7.0 Mobile/11A4449d Safari/9537.53`,
@@ -454,45 +510,88 @@ func TestHandlerMux(t *testing.T) {
 	}
 }
 
-func BenchmarkIsMobile(b *testing.B) {
+// benchmarkDetect runs f over and over on a BlackBerry. The User-Agent is set again before every run, which resets
+// what was memoized, so that the detection runs every time.
+func benchmarkDetect(b *testing.B, f func(detect *MobileDetect)) {
 	req, _ := http.NewRequest("GET", "URL", strings.NewReader(""))
 	detect := NewMobileDetect(req, nil)
-	detect.SetUserAgent(`Mozilla/5.0 (BlackBerry; U; BlackBerry 9700; en-US) AppleWebKit/534.8  (KHTML, like Gecko) Version/6.0.0.448 Mobile Safari/534.8`)
+	userAgent := `Mozilla/5.0 (BlackBerry; U; BlackBerry 9700; en-US) AppleWebKit/534.8  (KHTML, like Gecko) Version/6.0.0.448 Mobile Safari/534.8`
 	for n := 0; n < b.N; n++ {
-		detect.IsMobile()
+		detect.SetUserAgent(userAgent)
+		f(detect)
 	}
 }
 
-func BenchmarkIs(b *testing.B) {
+func BenchmarkIsMobile(b *testing.B) {
+	benchmarkDetect(b, func(detect *MobileDetect) { detect.IsMobile() })
+}
+
+func BenchmarkIsMobileDesktop(b *testing.B) {
 	req, _ := http.NewRequest("GET", "URL", strings.NewReader(""))
-	detect := NewMobileDetect(req, nil)
-	detect.SetUserAgent(`Mozilla/5.0 (BlackBerry; U; BlackBerry 9700; en-US) AppleWebKit/534.8  (KHTML, like Gecko) Version/6.0.0.448 Mobile Safari/534.8`)
+	req.Header.Set("User-Agent", `Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/61.0.3163.100 Safari/537.36`)
 	for n := 0; n < b.N; n++ {
-		detect.Is("iphone")
+		detect := NewMobileDetect(req, nil)
+		detect.IsMobile()
+		detect.IsTablet()
 	}
 }
-func BenchmarkIsKey(b *testing.B) {
+
+func BenchmarkIsMobileDesktopNoPrefilter(b *testing.B) {
 	req, _ := http.NewRequest("GET", "URL", strings.NewReader(""))
-	detect := NewMobileDetect(req, nil)
-	detect.SetUserAgent(`Mozilla/5.0 (BlackBerry; U; BlackBerry 9700; en-US) AppleWebKit/534.8  (KHTML, like Gecko) Version/6.0.0.448 Mobile Safari/534.8`)
+	req.Header.Set("User-Agent", `Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/61.0.3163.100 Safari/537.36`)
+	rules := NewRules().SetPrefilter(false)
 	for n := 0; n < b.N; n++ {
-		detect.IsKey(IPHONE)
+		detect := NewMobileDetect(req, rules)
+		detect.IsMobile()
+		detect.IsTablet()
 	}
 }
 
-func BenchmarkVersion(b *testing.B) {
-	req, _ := http.NewRequest("GET", "URL", strings.NewReader(""))
-	detect := NewMobileDetect(req, nil)
-	detect.SetUserAgent(`Mozilla/5.0 (BlackBerry; U; BlackBerry 9700; en-US) AppleWebKit/534.8  (KHTML, like Gecko) Version/6.0.0.448 Mobile Safari/534.8`)
+// BenchmarkIsMobileSequential runs the rules one regular expression after the other, the way
+// IsMobile and IsTablet used to, as a baseline for BenchmarkIsMobileDesktop.
+func BenchmarkIsMobileSequential(b *testing.B) {
+	userAgent := `Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/61.0.3163.100 Safari/537.36`
+	rules := NewRules()
+	compiled := make([]*regexp.Regexp, len(rules.mobileDetectionRules()))
+	for key, ruleValue := range rules.mobileDetectionRules() {
+		compiled[key] = regexp.MustCompile(`(?is)` + ruleValue)
+	}
+	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		detect.Version("iphone")
+		for _, re := range compiled {
+			if re.MatchString(userAgent) {
+				break
+			}
+		}
+		for _, re := range compiled[len(rules.phoneDevices) : len(rules.phoneDevices)+len(rules.tabletDevices)] {
+			if re.MatchString(userAgent) {
+				break
+			}
+		}
 	}
 }
-func BenchmarkVersionKey(b *testing.B) {
-	req, _ := http.NewRequest("GET", "URL", strings.NewReader(""))
-	detect := NewMobileDetect(req, nil)
-	detect.SetUserAgent(`Mozilla/5.0 (BlackBerry; U; BlackBerry 9700; en-US) AppleWebKit/534.8  (KHTML, like Gecko) Version/6.0.0.448 Mobile Safari/534.8`)
+
+func BenchmarkUaList(b *testing.B) {
 	for n := 0; n < b.N; n++ {
-		detect.VersionKey(PROP_IPHONE)
+		for _, test := range uaListTests {
+			detect := NewMobileDetect(httpRequest, nil)
+			detect.SetUserAgent(test.userAgent)
+			detect.IsMobile()
+			detect.IsTablet()
+		}
 	}
 }
+
+func BenchmarkIs(b *testing.B) {
+	benchmarkDetect(b, func(detect *MobileDetect) { detect.Is("iphone") })
+}
+func BenchmarkIsKey(b *testing.B) {
+	benchmarkDetect(b, func(detect *MobileDetect) { detect.IsKey(IPHONE) })
+}
+
+func BenchmarkVersion(b *testing.B) {
+	benchmarkDetect(b, func(detect *MobileDetect) { detect.Version("iphone") })
+}
+func BenchmarkVersionKey(b *testing.B) {
+	benchmarkDetect(b, func(detect *MobileDetect) { detect.VersionKey(PROP_IPHONE) })
+}
@@ -0,0 +1,81 @@
+package mobiledetect
+
+import "regexp"
+
+// matcher evaluates every detection rule against a User-Agent in a single pass.
+// Rules are compiled once and shared by all the MobileDetect objects created with the same rules.
+// Before running the (comparatively slow) regular expressions, the prefilter scans the User-Agent once
+// for the literals the rules require, which skips most rules on most requests.
+type matcher struct {
+	regexes   []*regexp.Regexp
+	literals  [][]string
+	prefilter *prefilter
+	// boundaries of the tablet rules and of the rules taking part in IsMobile
+	tabletStart int
+	tabletEnd   int
+	mobileEnd   int
+}
+
+// matchSet holds the result of a single pass, indexed by rule key.
+type matchSet []bool
+
+func newMatcher(r *rules) *matcher {
+	combined := r.mobileDetectionRules()
+	m := &matcher{
+		regexes:     make([]*regexp.Regexp, len(combined)),
+		literals:    make([][]string, len(combined)),
+		tabletStart: len(r.phoneDevices),
+		tabletEnd:   len(r.phoneDevices) + len(r.tabletDevices),
+		mobileEnd:   len(combined) - len(r.utilities),
+	}
+	for key, ruleValue := range combined {
+		if "" == ruleValue {
+			continue
+		}
+		m.regexes[key] = regexp.MustCompile(`(?is)` + ruleValue)
+		m.literals[key] = requiredLiterals(ruleValue)
+	}
+	m.prefilter = newPrefilter(m.literals)
+	return m
+}
+
+// match runs all the rules against the User-Agent and reports which of them matched.
+// Without the prefilter every regular expression is run, which is only useful to debug the prefilter itself.
+func (m *matcher) match(userAgent string, usePrefilter bool) matchSet {
+	set := make(matchSet, len(m.regexes))
+	var candidates []bool
+	if usePrefilter {
+		candidates = m.prefilter.candidates(userAgent)
+	}
+	for key, re := range m.regexes {
+		if nil == re || (nil != candidates && !candidates[key]) {
+			continue
+		}
+		set[key] = re.MatchString(userAgent)
+	}
+	return set
+}
+
+func (m *matcher) isMobile(set matchSet) bool {
+	return set.any(0, m.mobileEnd)
+}
+
+func (m *matcher) isTablet(set matchSet) bool {
+	return set.any(m.tabletStart, m.tabletEnd)
+}
+
+func (s matchSet) any(from, to int) bool {
+	for key := from; key < to && key < len(s); key++ {
+		if s[key] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s matchSet) has(key int) bool {
+	if key < 0 || key >= len(s) {
+		return false
+	}
+	return s[key]
+}
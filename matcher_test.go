@@ -0,0 +1,63 @@
+package mobiledetect
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// sequentialMatch is the straightforward way of running the rules: every regular expression, one after the other.
+func sequentialMatch(compiled []*regexp.Regexp, userAgent string) matchSet {
+	set := make(matchSet, len(compiled))
+	for key, re := range compiled {
+		set[key] = re.MatchString(userAgent)
+	}
+	return set
+}
+
+func TestMatcherSameAsSequential(t *testing.T) {
+	rules := NewRules()
+	compiled := make([]*regexp.Regexp, len(rules.mobileDetectionRules()))
+	for key, ruleValue := range rules.mobileDetectionRules() {
+		compiled[key] = regexp.MustCompile(`(?is)` + ruleValue)
+	}
+	for idx, test := range uaListTests {
+		expected := sequentialMatch(compiled, test.userAgent)
+		for _, usePrefilter := range []bool{true, false} {
+			actual := rules.compiled().match(test.userAgent, usePrefilter)
+			if !reflect.DeepEqual(expected, actual) {
+				for key := range expected {
+					if expected[key] != actual[key] {
+						t.Errorf("%d: rule %d for userAgent %s (prefilter %t) expected %t got %t", idx, key, test.userAgent, usePrefilter, expected[key], actual[key])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMatcherFoldsUserAgent(t *testing.T) {
+	m := NewRules().compiled()
+	// U+212A KELVIN SIGN and U+017F LATIN SMALL LETTER LONG S are case folds of ASCII letters
+	set := m.match("Mozilla/5.0 (Linux; Android 4.4; \u212Aindle Fire Build/KTU84P) Mobile Safari/537.36", true)
+	if !set.has(KINDLE) {
+		t.Error("Kindle rule should match a folded User-Agent")
+	}
+	set = m.match("Mozilla/5.0 (Linux; U; Android 2.3.6; en-us; Nexus \u017F Build/GRK39F) Mobile Safari/533.1", true)
+	if !set.has(NEXUS) {
+		t.Error("Nexus rule should match a folded User-Agent")
+	}
+}
+
+func TestSetPrefilter(t *testing.T) {
+	userAgent := `Mozilla/5.0 (Linux; U; Android 4.0.4; en-us; GT-P3113 Build/IMM76D) AppleWebKit/534.30 (KHTML, like Gecko) Version/4.0 Safari/534.30`
+	rules := NewRules()
+	expected := rules.match(userAgent)
+	actual := rules.SetPrefilter(false).match(userAgent)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Error("Results should be the same with and without the prefilter")
+	}
+	if !actual.has(SAMSUNGTABLET) {
+		t.Error("Samsung tablet rule should match")
+	}
+}
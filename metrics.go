@@ -0,0 +1,205 @@
+package mobiledetect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The upper bounds, in seconds, of the buckets of the detection latency histograms.
+var latencyBuckets = []float64{0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005, 0.001, 0.0025, 0.005, 0.01, 0.1}
+
+// The breakdowns counted by Metrics, in order.
+var metricDimensions = []string{"device", "os", "browser", "grade"}
+
+// Metrics counts the device classes, operating systems, browsers and grades detected by Handler, HandlerMux,
+// Middleware, Redirect and Detector, given WithMetrics, and measures how long the detection takes, with and
+// without the help of the cache. It is safe for concurrent use, and can be shared by several handlers.
+//
+// Metrics is an expvar.Var, published as JSON under /debug/vars with:
+//
+//	expvar.Publish("mobiledetect", metrics)
+//
+// and Handler serves the same figures in the text format of Prometheus.
+type Metrics struct {
+	mu      sync.Mutex
+	counts  map[string]map[string]uint64
+	latency map[string]*histogram
+}
+
+// histogram counts durations in latencyBuckets; counts are not cumulative.
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewMetrics creates metrics counting nothing yet.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		counts:  make(map[string]map[string]uint64, len(metricDimensions)),
+		latency: make(map[string]*histogram),
+	}
+	for _, dimension := range metricDimensions {
+		m.counts[dimension] = make(map[string]uint64)
+	}
+	return m
+}
+
+// WithMetrics records every detection in m, along with the details it needs, see WithDetails.
+func WithMetrics(m *Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+		o.details = true
+	}
+}
+
+// observe records a result, and how long it took. cache is "hit" when the result was cached, "miss" otherwise.
+func (m *Metrics) observe(res *Result, cache string, d time.Duration) {
+	device := res.Device()
+	if res.IsBot() {
+		device = DEVICE_BOT
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts["device"][device]++
+	m.counts["os"][metricValue(res.OS)]++
+	m.counts["browser"][metricValue(res.Browser)]++
+	m.counts["grade"][metricValue(res.Grade)]++
+	h, ok := m.latency[cache]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(latencyBuckets))}
+		m.latency[cache] = h
+	}
+	seconds := d.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// Counts returns the number of detections by value of the dimension: device, os, browser or grade.
+// Values the detection could not find, such as the OS of desktops, are counted as "unknown".
+func (m *Metrics) Counts(dimension string) map[string]uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[string]uint64, len(m.counts[dimension]))
+	for value, count := range m.counts[dimension] {
+		counts[value] = count
+	}
+	return counts
+}
+
+// String returns the metrics as JSON, for expvar.
+func (m *Metrics) String() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	type latency struct {
+		Count   uint64            `json:"count"`
+		Sum     float64           `json:"sum"`
+		Buckets map[string]uint64 `json:"buckets"`
+	}
+	vars := make(map[string]interface{}, len(m.counts)+1)
+	for dimension, counts := range m.counts {
+		vars[dimension] = counts
+	}
+	latencies := make(map[string]latency, len(m.latency))
+	for cache, h := range m.latency {
+		l := latency{Count: h.count, Sum: h.sum, Buckets: make(map[string]uint64, len(latencyBuckets)+1)}
+		for i, count := range h.cumulative() {
+			l.Buckets[bucketBound(i)] = count
+		}
+		latencies[cache] = l
+	}
+	vars["latency"] = latencies
+	b, _ := json.Marshal(vars)
+	return string(b)
+}
+
+// Handler serves the metrics in the text exposition format of Prometheus, e.g. on /metrics.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.writePrometheus(w)
+	})
+}
+
+func (m *Metrics) writePrometheus(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, dimension := range metricDimensions {
+		name := "mobiledetect_" + dimension + "_total"
+		fmt.Fprintf(w, "# HELP %s Detected requests by %s.\n# TYPE %s counter\n", name, dimension, name)
+		counts := m.counts[dimension]
+		for _, value := range sortedKeys(counts) {
+			fmt.Fprintf(w, "%s{%s=\"%s\"} %d\n", name, dimension, escapeLabel(value), counts[value])
+		}
+	}
+	name := "mobiledetect_detection_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Time taken by the detection, by cache outcome.\n# TYPE %s histogram\n", name, name)
+	caches := make([]string, 0, len(m.latency))
+	for cache := range m.latency {
+		caches = append(caches, cache)
+	}
+	sort.Strings(caches)
+	for _, cache := range caches {
+		h := m.latency[cache]
+		for i, count := range h.cumulative() {
+			fmt.Fprintf(w, "%s_bucket{cache=\"%s\",le=\"%s\"} %d\n", name, cache, bucketBound(i), count)
+		}
+		fmt.Fprintf(w, "%s_sum{cache=\"%s\"} %s\n", name, cache, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count{cache=\"%s\"} %d\n", name, cache, h.count)
+	}
+}
+
+// cumulative returns the number of durations up to every bound, the last one being +Inf.
+func (h *histogram) cumulative() []uint64 {
+	counts := make([]uint64, len(h.counts)+1)
+	var total uint64
+	for i, count := range h.counts {
+		total += count
+		counts[i] = total
+	}
+	counts[len(h.counts)] = h.count
+	return counts
+}
+
+// bucketBound returns the upper bound of the ith bucket as Prometheus writes it.
+func bucketBound(i int) string {
+	if i == len(latencyBuckets) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(latencyBuckets[i], 'g', -1, 64)
+}
+
+func sortedKeys(counts map[string]uint64) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func metricValue(value string) string {
+	if "" == value {
+		return "unknown"
+	}
+	return value
+}
@@ -0,0 +1,88 @@
+package mobiledetect
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	handler := HandlerMux(http.NewServeMux(), nil, WithMetrics(metrics), WithCache(NewCache(10)))
+	for _, userAgent := range []string{iPhoneUserAgent, iPhoneUserAgent, iPadUserAgent, desktopUserAgent, googlebotUserAgent} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", userAgent)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	devices := metrics.Counts("device")
+	expected := map[string]uint64{DEVICE_MOBILE: 2, DEVICE_TABLET: 1, DEVICE_DESKTOP: 1, DEVICE_BOT: 1}
+	if len(expected) != len(devices) {
+		t.Errorf("Expected %v got %v", expected, devices)
+	}
+	for device, count := range expected {
+		if count != devices[device] {
+			t.Errorf("Expected %d %s got %d", count, device, devices[device])
+		}
+	}
+	if 3 != metrics.Counts("os")["iOS"] || 2 != metrics.Counts("os")["unknown"] {
+		t.Errorf("Unexpected OS counts %v", metrics.Counts("os"))
+	}
+
+	var vars struct {
+		Device  map[string]uint64
+		Latency map[string]struct {
+			Count   uint64
+			Buckets map[string]uint64
+		}
+	}
+	if err := json.Unmarshal([]byte(metrics.String()), &vars); nil != err {
+		t.Fatalf("Invalid JSON %s (%v)", metrics.String(), err)
+	}
+	if 2 != vars.Device[DEVICE_MOBILE] || 1 != vars.Latency["hit"].Count || 4 != vars.Latency["miss"].Count || 4 != vars.Latency["miss"].Buckets["+Inf"] {
+		t.Errorf("Unexpected expvar %s", metrics.String())
+	}
+	expvar.Publish("mobiledetect_test", metrics)
+	if metrics.String() != expvar.Get("mobiledetect_test").String() {
+		t.Errorf("Expected the metrics to be published")
+	}
+}
+
+func TestMetricsPrometheus(t *testing.T) {
+	metrics := NewMetrics()
+	detector := NewDetector(WithMetrics(metrics))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	detector.Detect(r)
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE mobiledetect_device_total counter",
+		`mobiledetect_device_total{device="Mobile"} 1`,
+		`mobiledetect_os_total{os="iOS"} 1`,
+		`mobiledetect_grade_total{grade="A"} 1`,
+		"# TYPE mobiledetect_detection_duration_seconds histogram",
+		`mobiledetect_detection_duration_seconds_bucket{cache="miss",le="+Inf"} 1`,
+		`mobiledetect_detection_duration_seconds_count{cache="miss"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, `cache="hit"`) {
+		t.Errorf("Expected no cache hits in\n%s", body)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if `a\"b\\c\nd` != escapeLabel("a\"b\\c\nd") {
+		t.Errorf("Unexpected escaping %s", escapeLabel("a\"b\\c\nd"))
+	}
+}
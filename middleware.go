@@ -0,0 +1,26 @@
+package mobiledetect
+
+import "net/http"
+
+// Middleware detects the device of every request and stores the result in the request context,
+// where FromContext finds it, before handing the request to the next handler.
+// It wraps any http.Handler and can be chained with other middleware:
+//
+//	mobiledetect.Middleware(mobiledetect.WithCache(cache))(handler)
+func Middleware(opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(nil, opts)
+	return func(next http.Handler) http.Handler {
+		return o.middleware(next)
+	}
+}
+
+func (o *options) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, res := o.detect(r)
+		res = o.applyOverride(w, r, res)
+		o.setResponseHeaders(w, res)
+		r = r.WithContext(o.newContext(r.Context(), res))
+		o.setBucketHeader(r, res)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,91 @@
+package mobiledetect
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const desktopUserAgent = `Mozilla/5.0 (Windows NT 6.1; rv:40.0) Gecko/20100101 Firefox/40.0`
+
+func serveMiddleware(r *http.Request, opts ...Option) *Result {
+	var res *Result
+	h := Middleware(opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, _ = FromContext(r.Context())
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	return res
+}
+
+func TestMiddleware(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", `Mozilla/5.0 (iPad; CPU OS 5_1_1 like Mac OS X; en-us) AppleWebKit/534.46.0 (KHTML, like Gecko) CriOS/21.0.1180.80 Mobile/9B206 Safari/7534.48.3 (6FF046A0-1BC4-4E7D-8A9D-6BF17622A123)`)
+	res := serveMiddleware(r)
+	if nil == res || DEVICE_TABLET != res.Device() {
+		t.Errorf("Expected a tablet, got %+v", res)
+	}
+
+	cache := NewCache(10)
+	serveMiddleware(r, WithCache(cache), WithRules(NewRules()))
+	serveMiddleware(r, WithCache(cache))
+	if 1 != cache.Hits() {
+		t.Errorf("Expected a cache hit, got %d", cache.Hits())
+	}
+}
+
+func TestMiddlewareTrustedHeaders(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", desktopUserAgent)
+	r.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	r.Header.Set("X-Wap-Profile", "http://nds.nokia.com/uaprof/N6230r200.xml")
+	trusted := WithTrustedHeaders(DefaultTrustedHeaders()...)
+	if res := serveMiddleware(r, trusted); !res.Mobile {
+		t.Error("X-Wap-Profile should make the request mobile")
+	}
+	if res := serveMiddleware(r); res.Mobile {
+		t.Error("Headers should be ignored unless trusted")
+	}
+	if res := serveMiddleware(r, WithTrustedHeaders("x-wap-profile")); !res.Mobile {
+		t.Error("Trusted X-Wap-Profile should make the request mobile")
+	}
+	r.Header.Del("X-Wap-Profile")
+	if res := serveMiddleware(r, trusted); res.Mobile {
+		t.Error("Accept without WAP types should not make the request mobile")
+	}
+	r.Header.Set("Ua-Cpu", "x86")
+	r.Header.Set("X-Huawei-Userid", "1234")
+	if res := serveMiddleware(r, trusted); !res.Mobile {
+		t.Error("X-Huawei-Userid should make the request mobile whatever the headers before it")
+	}
+}
+
+func TestMiddlewareChain(t *testing.T) {
+	var device string
+	h := Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		device = Device(r)
+	}))
+	outer := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("User-Agent", `Mozilla/5.0 (iPod touch; CPU iPhone OS 7_0 like Mac OS X) AppleWebKit/537.51.1 (KHTML, like Gecko) Version/7.0 Mobile/11A4449d Safari/9537.53`)
+		h.ServeHTTP(w, r)
+	})
+	r, _ := http.NewRequest("GET", "/", nil)
+	outer.ServeHTTP(httptest.NewRecorder(), r)
+	if DEVICE_MOBILE != device {
+		t.Errorf("Expected Mobile, got %s", device)
+	}
+}
+
+func BenchmarkMiddleware(b *testing.B) {
+	for _, details := range []bool{false, true} {
+		b.Run(fmt.Sprintf("details=%v", details), func(b *testing.B) {
+			h := Middleware(WithDetails(details))(http.NotFoundHandler())
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("User-Agent", desktopUserAgent)
+			r.Header.Set("Accept", "text/html")
+			for i := 0; i < b.N; i++ {
+				h.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		})
+	}
+}
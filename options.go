@@ -0,0 +1,201 @@
+package mobiledetect
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// User-Agents longer than this many bytes are truncated by default, see WithMaxUserAgentLength.
+	DEFAULT_MAX_USER_AGENT_LENGTH = 1024
+	// Header values longer than this many bytes are truncated by default, see WithMaxHeaderBytes.
+	DEFAULT_MAX_HEADER_BYTES = 2048
+)
+
+// The request headers some mobile browsers, gateways and proxies are known to send, see DefaultTrustedHeaders.
+var defaultTrustedHeaders = []string{
+	"Accept",
+	"X-Wap-Profile",
+	"X-Wap-Clientid",
+	"Wap-Connection",
+	"Profile",
+	"X-Operamini-Phone-Ua",
+	"X-Operamini-Features",
+	"X-Nokia-Gateway-Id",
+	"X-Orange-Id",
+	"X-Vodafone-3gpdpcontext",
+	"X-Huawei-Userid",
+	"Ua-Os",
+	"X-Mobile-Gateway",
+	"X-Att-Deviceid",
+	"Ua-Cpu",
+}
+
+// Option changes the way NewMobileDetect, Handler, HandlerMux and Middleware detect requests.
+type Option func(*options)
+
+type options struct {
+	rules              *rules
+	cache              *Cache
+	maxUserAgentLength int
+	maxHeaderBytes     int
+	trustedHeaders     []string
+	override           *OverrideConfig
+	forwarded          *ForwardedUserAgentConfig
+	carriers           []Carrier
+	noVary             bool
+	deviceClassHeader  string
+	bucketHeader       string
+	bucketGrade        bool
+	metrics            *Metrics
+	details            bool
+	// derive more values from the result for the request context, e.g. a logger
+	contextHooks []func(context.Context, *Result) context.Context
+}
+
+// WithRules detects requests with the given rules instead of the default ones.
+func WithRules(rules *rules) Option {
+	return func(o *options) {
+		if nil != rules {
+			o.rules = rules
+		}
+	}
+}
+
+// WithCache looks results up in the cache before running the detection, and stores them there afterwards.
+func WithCache(cache *Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithMaxUserAgentLength limits the part of the User-Agent matched against the rules to its first n bytes,
+// so that clients can't make detection arbitrarily slow by sending huge User-Agents. Longer User-Agents are
+// truncated, at a UTF-8 boundary, before anything else happens: tokens past the limit are simply not seen,
+// and Result.UserAgent holds the truncated value. A limit of 0 or less turns truncation off.
+func WithMaxUserAgentLength(n int) Option {
+	return func(o *options) {
+		o.maxUserAgentLength = n
+	}
+}
+
+// WithMaxHeaderBytes limits the part of every header value inspected by CheckHttpHeadersForMobile
+// to its first n bytes, truncated the same way as the User-Agent. A limit of 0 or less turns truncation off.
+func WithMaxHeaderBytes(n int) Option {
+	return func(o *options) {
+		o.maxHeaderBytes = n
+	}
+}
+
+// WithDetails tells whether Result.Grade, OS, OSVersion, Browser, BrowserVersion and ProxyMode are filled in,
+// which takes about four times as long as finding the class of the device. WithMetrics, WithLogger and graded
+// buckets turn them on, and so do Detector, Director and NewReverseProxy unless told otherwise.
+func WithDetails(enabled bool) Option {
+	return func(o *options) {
+		o.details = enabled
+	}
+}
+
+// DefaultTrustedHeaders returns the headers some mobile browsers, gateways and proxies are known to send, such as
+// Accept or X-Wap-Profile, to be given to WithTrustedHeaders, possibly extended.
+func DefaultTrustedHeaders() []string {
+	return append([]string(nil), defaultTrustedHeaders...)
+}
+
+// WithTrustedHeaders sets the request headers, besides the User-Agent, which are taken into account. By default
+// none are: these headers are sent by the clients, and only the ones a proxy in front passes through untouched
+// should be trusted, e.g. WithTrustedHeaders(DefaultTrustedHeaders()...). Any of them makes IsMobile true, except
+// Accept and Ua-Cpu which only do when they hold WAP content types or ARM. Carrier, ProxyMode and PhoneTier
+// read them too.
+func WithTrustedHeaders(names ...string) Option {
+	return func(o *options) {
+		o.trustedHeaders = names
+	}
+}
+
+func newOptions(rules *rules, opts []Option) *options {
+	if nil == rules {
+		rules = defaultRules
+	}
+	o := &options{
+		rules:              rules,
+		maxUserAgentLength: DEFAULT_MAX_USER_AGENT_LENGTH,
+		maxHeaderBytes:     DEFAULT_MAX_HEADER_BYTES,
+		carriers:           defaultCarriers,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// detect runs the detection for the request, or takes its result from the cache.
+func (o *options) detect(r *http.Request) (*MobileDetect, *Result) {
+	if nil == o.metrics {
+		md := o.newMobileDetect(r)
+		res, _ := o.result(md)
+		return md, res
+	}
+	start := time.Now()
+	md := o.newMobileDetect(r)
+	res, cached := o.result(md)
+	cache := "miss"
+	if cached {
+		cache = "hit"
+	}
+	o.metrics.observe(res, cache, time.Since(start))
+	return md, res
+}
+
+// result runs the detection, or takes its result from the cache, and tells which.
+func (o *options) result(md *MobileDetect) (*Result, bool) {
+	if nil == o.cache {
+		return o.newResult(md), false
+	}
+	key := md.cacheKey()
+	if res, ok := o.cache.get(key); ok {
+		return res, true
+	}
+	res := o.newResult(md)
+	o.cache.add(key, res)
+	return res, false
+}
+
+func (o *options) newResult(md *MobileDetect) *Result {
+	if o.details {
+		return md.Result()
+	}
+	return md.basicResult()
+}
+
+// detailed returns res, a result found in the request context, or the result of detecting the request
+// again when res lacks the fields of WithDetails. The device class forced by the user, if any, is kept.
+func (o *options) detailed(r *http.Request, res *Result) *Result {
+	if res.detailed {
+		return res
+	}
+	_, detailed := o.detect(r)
+	if res.Overridden {
+		detailed = detailed.overridden(res.Device())
+	}
+	return detailed
+}
+
+// cgiHeaderName turns a header name such as X-Wap-Profile into the name of the CGI variable holding it, HTTP_X_WAP_PROFILE.
+func cgiHeaderName(name string) string {
+	return "HTTP_" + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence. It leaves s alone when n is 0 or less.
+func truncate(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,96 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"strings"
+)
+
+// OverrideConfig names where users, or testers, can force the class of their device whatever the detection says.
+// Every source is optional; when several are set the header wins over the query parameter, which wins over the cookie.
+// Accepted values are mobile (or phone), tablet and desktop, case insensitive.
+type OverrideConfig struct {
+	// Param is the query parameter forcing the class, e.g. ?device=desktop. The choice is remembered
+	// in Cookie, if set, until another value, such as ?device=auto, asks to detect the device again.
+	Param string
+	// Cookie holds the class chosen earlier through Param.
+	Cookie string
+	// Header forces the class of a single request, e.g. X-Force-Device: tablet; meant for testing.
+	Header string
+}
+
+// WithOverride lets the sources described by config force the device class found by Handler, HandlerMux,
+// Middleware and Redirect. The result then tells the forced class from the detected one, see Result.Overridden.
+func WithOverride(config OverrideConfig) Option {
+	return func(o *options) {
+		o.override = &config
+	}
+}
+
+// forcedDevice returns the device class asked for by the override sources, if any, keeping the cookie up to date.
+func (config *OverrideConfig) forcedDevice(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if "" != config.Header {
+		if device, ok := parseDevice(r.Header.Get(config.Header)); ok {
+			return device, true
+		}
+	}
+	if "" != config.Param {
+		if values, ok := r.URL.Query()[config.Param]; ok && 0 != len(values) {
+			device, ok := parseDevice(values[0])
+			if "" != config.Cookie {
+				cookie := &http.Cookie{Name: config.Cookie, Value: strings.ToLower(device), Path: "/"}
+				if !ok {
+					cookie.Value = ""
+					cookie.MaxAge = -1
+				}
+				http.SetCookie(w, cookie)
+				// the cookie is for this user only, shared caches must not hand it to others
+				addCacheControl(w.Header(), "private")
+			}
+			return device, ok
+		}
+	}
+	if "" != config.Cookie {
+		if cookie, err := r.Cookie(config.Cookie); nil == err {
+			return parseDevice(cookie.Value)
+		}
+	}
+	return "", false
+}
+
+func parseDevice(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "mobile", "phone":
+		return DEVICE_MOBILE, true
+	case "tablet":
+		return DEVICE_TABLET, true
+	case "desktop":
+		return DEVICE_DESKTOP, true
+	}
+	return "", false
+}
+
+// overridden returns a copy of the result with the device class forced, leaving the (possibly cached) result alone.
+func (res *Result) overridden(device string) *Result {
+	forced := *res
+	forced.Detected = res.Device()
+	forced.Mobile = DEVICE_MOBILE == device || DEVICE_TABLET == device
+	forced.Tablet = DEVICE_TABLET == device
+	forced.Overridden = true
+	if DEVICE_MOBILE != device {
+		forced.PhoneTier = ""
+	} else if "" == forced.PhoneTier {
+		forced.PhoneTier = PHONE_TIER_SMARTPHONE
+	}
+	return &forced
+}
+
+// applyOverride forces the class of the result if the request asks for it.
+func (o *options) applyOverride(w http.ResponseWriter, r *http.Request, res *Result) *Result {
+	if nil == o.override {
+		return res
+	}
+	if device, ok := o.override.forcedDevice(w, r); ok {
+		return res.overridden(device)
+	}
+	return res
+}
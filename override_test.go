@@ -0,0 +1,91 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var overrideConfig = OverrideConfig{Param: "device", Cookie: "device", Header: "X-Force-Device"}
+
+func overrideRequest(t *testing.T, r *http.Request, opts ...Option) (*Result, *httptest.ResponseRecorder) {
+	var res *Result
+	h := Middleware(opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, _ = FromContext(r.Context())
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if nil == res {
+		t.Fatal("Result should be in the context")
+	}
+	return res, w
+}
+
+func TestOverride(t *testing.T) {
+	cache := NewCache(10)
+	data := []struct {
+		url, header, cookie, userAgent string
+		device                         string
+		overridden                     bool
+		setCookie                      string
+	}{
+		{"/", "", "", iPhoneUserAgent, DEVICE_MOBILE, false, ""},
+		{"/?device=desktop", "", "", iPhoneUserAgent, DEVICE_DESKTOP, true, "device=desktop"},
+		{"/?device=Tablet", "", "", desktopUserAgent, DEVICE_TABLET, true, "device=tablet"},
+		{"/?device=phone", "", "", desktopUserAgent, DEVICE_MOBILE, true, "device=mobile"},
+		{"/", "", "desktop", iPhoneUserAgent, DEVICE_DESKTOP, true, ""},
+		{"/?device=auto", "", "desktop", iPhoneUserAgent, DEVICE_MOBILE, false, "device=; Path=/; Max-Age=0"},
+		{"/?device=desktop", "tablet", "mobile", iPhoneUserAgent, DEVICE_TABLET, true, ""},
+		{"/", "bogus", "", iPhoneUserAgent, DEVICE_MOBILE, false, ""},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", d.url, nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		if "" != d.header {
+			r.Header.Set("X-Force-Device", d.header)
+		}
+		if "" != d.cookie {
+			r.AddCookie(&http.Cookie{Name: "device", Value: d.cookie})
+		}
+		res, w := overrideRequest(t, r, WithOverride(overrideConfig), WithCache(cache))
+		if d.device != res.Device() || d.overridden != res.Overridden {
+			t.Errorf("%s: expected %s (overridden %t) got %s (overridden %t)", d.url, d.device, d.overridden, res.Device(), res.Overridden)
+		}
+		if setCookie := w.Header().Get("Set-Cookie"); !(d.setCookie == setCookie || "" != d.setCookie && d.setCookie+"; Path=/" == setCookie) {
+			t.Errorf("%s: expected cookie %q got %q", d.url, d.setCookie, setCookie)
+		}
+		if res.Overridden && (iPhoneUserAgent == d.userAgent) != (DEVICE_MOBILE == res.Detected) {
+			t.Errorf("%s: detected class should be kept, got %s", d.url, res.Detected)
+		}
+	}
+
+	// forced classes must not leak into the cache
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	if res, _ := overrideRequest(t, r, WithCache(cache)); DEVICE_MOBILE != res.Device() || res.Overridden {
+		t.Errorf("Cached result was modified: %s", res.Device())
+	}
+}
+
+func TestOverrideDisabled(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?device=desktop", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	r.Header.Set("X-Force-Device", "desktop")
+	if res, _ := overrideRequest(t, r); DEVICE_MOBILE != res.Device() || res.Overridden {
+		t.Error("Without WithOverride the device class should not be forced")
+	}
+}
+
+func TestOverrideHandler(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?device=tablet", nil)
+	r.Header.Set("User-Agent", desktopUserAgent)
+	w := httptest.NewRecorder()
+	h := &basicMethodsStruct{}
+	Handler(h, nil, WithOverride(OverrideConfig{Param: "device"})).ServeHTTP(w, r)
+	if "tablet" != h.handlerCalled {
+		t.Errorf("Handler should dispatch on the forced class, got %q", h.handlerCalled)
+	}
+	if "" != w.Header().Get("Set-Cookie") {
+		t.Error("No cookie should be set without OverrideConfig.Cookie")
+	}
+}
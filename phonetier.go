@@ -0,0 +1,51 @@
+package mobiledetect
+
+import "strings"
+
+// The phone tiers PhoneTier reports.
+const (
+	PHONE_TIER_SMARTPHONE = "Smartphone"
+	// Feature phones, such as Series40 Nokias or J2ME devices, are best served text-only pages.
+	PHONE_TIER_FEATURE = "FeaturePhone"
+)
+
+// Operating systems of smartphones; some of their User-Agents mention J2ME or Symbian as well.
+var smartphoneKeys = []int{IOS, ANDROIDOS, WINDOWSPHONEOS, WINDOWSMOBILEOS, BLACKBERRYOS, WEBOS, MEEGOOS, MAEMOOS, BADAOS}
+
+// Operating systems and browsers only found on feature phones.
+var featurePhoneKeys = []int{JAVAOS, SYMBIANOS, BREWOS, NETFRONT, OBIGOBROWSER}
+
+// Accept header types of WAP browsers; WML is only ever asked for by feature phones.
+var featurePhoneAccepts = []string{"text/vnd.wap.wml"}
+
+// PhoneTier returns PHONE_TIER_FEATURE for feature phones, told by their operating systems (J2ME, Symbian,
+// BREW), browsers (NetFront, Obigo) or WAP Accept headers, PHONE_TIER_SMARTPHONE for the other phones,
+// and nothing for tablets and desktops.
+func (md *MobileDetect) PhoneTier() string {
+	if !md.IsMobile() || md.IsTablet() {
+		return ""
+	}
+	for _, key := range smartphoneKeys {
+		if md.IsKey(key) {
+			return PHONE_TIER_SMARTPHONE
+		}
+	}
+	for _, key := range featurePhoneKeys {
+		if md.IsKey(key) {
+			return PHONE_TIER_FEATURE
+		}
+	}
+	if accept, ok := md.httpHeader("HTTP_ACCEPT"); ok {
+		for _, contentType := range featurePhoneAccepts {
+			if strings.Contains(accept, contentType) {
+				return PHONE_TIER_FEATURE
+			}
+		}
+	}
+	return PHONE_TIER_SMARTPHONE
+}
+
+// IsFeaturePhone tells whether the device is a feature phone rather than a smartphone, see PhoneTier.
+func (md *MobileDetect) IsFeaturePhone() bool {
+	return PHONE_TIER_FEATURE == md.PhoneTier()
+}
@@ -0,0 +1,57 @@
+package mobiledetect
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPhoneTier(t *testing.T) {
+	data := []struct {
+		userAgent string
+		accept    string
+		expected  string
+	}{
+		{`Nokia200/2.0 (12.04) Profile/MIDP-2.1 Configuration/CLDC-1.1 UCWEB/2.0 (Java; U; MIDP-2.0; en-US; nokia200) U2/1.0.0 UCBrowser/8.9.0.251 U2/1.0.0 Mobile UNTRUSTED/1.0`, "", PHONE_TIER_FEATURE},
+		{`Opera/9.80 (J2ME/MIDP; Opera Mini/9.80 (S60; SymbOS; Opera Mobi/23.348; U; en) Presto/2.5.25 Version/10.54`, "", PHONE_TIER_FEATURE},
+		{`Nokia6230i/2.0 (03.80) Profile/MIDP-2.0 Configuration/CLDC-1.1`, "", PHONE_TIER_FEATURE},
+		{`SAMSUNG-SGH-A867/A867UCHJ3 SHP/VPP/R5 NetFront/35 SMM-MMS/1.2.0 profile/MIDP-2.0 configuration/CLDC-1.1`, "", PHONE_TIER_FEATURE},
+		{`Opera/9.80 (Android; Opera Mini/7.0.29952/28.2647; U; ru) Presto/2.8.119 Version/11.10`, "", PHONE_TIER_SMARTPHONE},
+		{iPhoneUserAgent, "", PHONE_TIER_SMARTPHONE},
+		{`Mozilla/5.0 (Linux; Android 4.1.2; GT-I9100 Build/JZO54K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/30.0.0.0 Mobile Safari/537.36`, "", PHONE_TIER_SMARTPHONE},
+		{"", "text/vnd.wap.wml, image/gif", PHONE_TIER_FEATURE},
+		{"", "application/vnd.wap.xhtml+xml, text/html", PHONE_TIER_SMARTPHONE},
+		{iPadUserAgent, "", ""},
+		{desktopUserAgent, "", ""},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		if "" != d.accept {
+			r.Header.Set("Accept", d.accept)
+		}
+		md := NewMobileDetect(r, nil, WithTrustedHeaders(DefaultTrustedHeaders()...))
+		if d.expected != md.PhoneTier() || (PHONE_TIER_FEATURE == d.expected) != md.IsFeaturePhone() {
+			t.Errorf("Expected %q got %q for %s %s", d.expected, md.PhoneTier(), d.userAgent, d.accept)
+		}
+		if res := md.Result(); d.expected != res.PhoneTier || (PHONE_TIER_FEATURE == d.expected) != res.IsFeaturePhone() {
+			t.Errorf("Expected the result to have %q got %q", d.expected, res.PhoneTier)
+		}
+	}
+}
+
+func TestPhoneTierOverridden(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", `Nokia6230i/2.0 (03.80) Profile/MIDP-2.0 Configuration/CLDC-1.1`)
+	res := NewMobileDetect(r, nil).Result()
+	if forced := res.overridden(DEVICE_MOBILE); PHONE_TIER_FEATURE != forced.PhoneTier {
+		t.Errorf("Expected the tier to be kept got %q", forced.PhoneTier)
+	}
+	if forced := res.overridden(DEVICE_DESKTOP); "" != forced.PhoneTier {
+		t.Errorf("Expected no tier for desktops got %q", forced.PhoneTier)
+	}
+	r.Header.Set("User-Agent", desktopUserAgent)
+	res = NewMobileDetect(r, nil).Result()
+	if forced := res.overridden(DEVICE_MOBILE); PHONE_TIER_SMARTPHONE != forced.PhoneTier {
+		t.Errorf("Expected desktops forced to mobile to be smartphones got %q", forced.PhoneTier)
+	}
+}
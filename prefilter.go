@@ -0,0 +1,339 @@
+package mobiledetect
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+const (
+	// Sets of exact strings larger than this are turned into a requirement on one of their parts.
+	maxExactLiterals = 64
+	// Character classes with more runes than this are not expanded into literals.
+	maxCharClassRunes = 10
+)
+
+// prefilter finds, in a single scan of the User-Agent, the rules that can possibly match it.
+// Every rule contributes the literals of which at least one appears in any text it matches
+// (see requiredLiterals), and all of them are searched for at once with an Aho-Corasick automaton.
+// Rules without literals are always candidates.
+type prefilter struct {
+	// byteClass maps the bytes used by the literals (upper and lower cased) to the columns of delta, every other byte to 0
+	byteClass [256]int32
+	width     int32
+	// delta is the transition table of the automaton, state*width + byteClass[b] gives the next state
+	delta []int32
+	// output holds, for each state, the keys of the rules having a literal ending at that state
+	output [][]int
+	// unfiltered rules are candidates for every User-Agent
+	unfiltered []int
+	size       int
+}
+
+func newPrefilter(literals [][]string) *prefilter {
+	p := &prefilter{size: len(literals)}
+
+	for _, ruleLiterals := range literals {
+		for _, literal := range ruleLiterals {
+			for i := 0; i < len(literal); i++ {
+				if 0 == p.byteClass[literal[i]] {
+					p.width++
+					p.byteClass[literal[i]] = p.width
+				}
+			}
+		}
+	}
+	p.width++
+	for b := 'a'; b <= 'z'; b++ {
+		p.byteClass[b-'a'+'A'] = p.byteClass[b]
+	}
+
+	// the trie of all literals, state 0 being the root
+	p.delta = make([]int32, p.width)
+	p.output = [][]int{nil}
+	for key, ruleLiterals := range literals {
+		if nil == ruleLiterals {
+			p.unfiltered = append(p.unfiltered, key)
+			continue
+		}
+		for _, literal := range ruleLiterals {
+			state := int32(0)
+			for i := 0; i < len(literal); i++ {
+				transition := state*p.width + p.byteClass[literal[i]]
+				if 0 == p.delta[transition] {
+					p.delta[transition] = int32(len(p.output))
+					p.delta = append(p.delta, make([]int32, p.width)...)
+					p.output = append(p.output, nil)
+				}
+				state = p.delta[transition]
+			}
+			p.output[state] = appendKey(p.output[state], key)
+		}
+	}
+
+	// turn the trie into an automaton: missing transitions follow the failure links,
+	// and every state also reports the literals of its failure state (its longest proper suffix)
+	fail := make([]int32, len(p.output))
+	queue := []int32{}
+	for c := int32(0); c < p.width; c++ {
+		if next := p.delta[c]; 0 != next {
+			queue = append(queue, next)
+		}
+	}
+	for 0 != len(queue) {
+		state := queue[0]
+		queue = queue[1:]
+		for _, key := range p.output[fail[state]] {
+			p.output[state] = appendKey(p.output[state], key)
+		}
+		for c := int32(0); c < p.width; c++ {
+			next := p.delta[state*p.width+c]
+			if 0 == next {
+				p.delta[state*p.width+c] = p.delta[fail[state]*p.width+c]
+				continue
+			}
+			fail[next] = p.delta[fail[state]*p.width+c]
+			queue = append(queue, next)
+		}
+	}
+	return p
+}
+
+func appendKey(keys []int, key int) []int {
+	for _, k := range keys {
+		if k == key {
+			return keys
+		}
+	}
+	return append(keys, key)
+}
+
+// candidates returns the rules which might match the User-Agent.
+func (p *prefilter) candidates(userAgent string) []bool {
+	candidates := make([]bool, p.size)
+	for _, key := range p.unfiltered {
+		candidates[key] = true
+	}
+	// ASCII letters are folded by byteClass, anything else needs to go through foldUserAgent first
+	for i := 0; i < len(userAgent); i++ {
+		if userAgent[i] >= 0x80 {
+			userAgent = foldUserAgent(userAgent)
+			break
+		}
+	}
+	state := int32(0)
+	for i := 0; i < len(userAgent); i++ {
+		state = p.delta[state*p.width+p.byteClass[userAgent[i]]]
+		for _, key := range p.output[state] {
+			candidates[key] = true
+		}
+	}
+	return candidates
+}
+
+// The rules are case insensitive, so literals and User-Agents are compared lower cased.
+// U+017F (long s) is the only rune strings.ToLower doesn't bring back to its ASCII fold.
+var userAgentFolder = strings.NewReplacer("ſ", "s")
+
+func foldUserAgent(userAgent string) string {
+	return userAgentFolder.Replace(strings.ToLower(userAgent))
+}
+
+// requiredLiterals returns a list of lower cased strings of which at least one appears in every
+// text matched by the rule, or nil if no such list can be found.
+func requiredLiterals(ruleValue string) []string {
+	re, err := syntax.Parse(`(?is)`+ruleValue, syntax.Perl)
+	if nil != err {
+		return nil
+	}
+	return literalsOf(re.Simplify()).best()
+}
+
+// literalInfo describes the texts matched by a regular expression.
+type literalInfo struct {
+	// exact lists every text the expression can match, nil if they are unknown or too many
+	exact []string
+	// required lists strings of which at least one is part of every match, nil if unknown
+	required []string
+}
+
+// best returns the most useful requirement the expression provides.
+func (info literalInfo) best() []string {
+	if nil == info.exact {
+		return info.required
+	}
+	for _, literal := range info.exact {
+		// the expression may match an empty text, nothing is really required
+		if "" == literal {
+			return nil
+		}
+	}
+	return info.exact
+}
+
+func literalsOf(re *syntax.Regexp) literalInfo {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return literalInfo{exact: []string{""}}
+	case syntax.OpLiteral:
+		literal, ok := foldLiteral(re.Rune)
+		if !ok {
+			return literalInfo{}
+		}
+		return literalInfo{exact: []string{literal}}
+	case syntax.OpCharClass:
+		return charClassLiterals(re.Rune)
+	case syntax.OpCapture:
+		return literalsOf(re.Sub[0])
+	case syntax.OpQuest:
+		sub := literalsOf(re.Sub[0])
+		if nil == sub.exact {
+			return literalInfo{}
+		}
+		return literalInfo{exact: union(sub.exact, []string{""})}
+	case syntax.OpPlus:
+		return literalInfo{required: literalsOf(re.Sub[0]).best()}
+	case syntax.OpRepeat:
+		if re.Min < 1 {
+			return literalInfo{}
+		}
+		return literalInfo{required: literalsOf(re.Sub[0]).best()}
+	case syntax.OpConcat:
+		return concatLiterals(re.Sub)
+	case syntax.OpAlternate:
+		return alternateLiterals(re.Sub)
+	}
+	return literalInfo{}
+}
+
+// concatLiterals combines the parts of a concatenation. Exact strings are multiplied as long as there are
+// not too many of them; when that is no longer possible, the best requirement found so far is kept.
+func concatLiterals(subs []*syntax.Regexp) literalInfo {
+	exact := []string{""}
+	var required []string
+	complete := true
+	for _, sub := range subs {
+		info := literalsOf(sub)
+		if nil != exact && nil != info.exact && len(exact)*len(info.exact) <= maxExactLiterals {
+			exact = cross(exact, info.exact)
+			continue
+		}
+		complete = false
+		required = moreSelective(required, literalInfo{exact: exact}.best())
+		required = moreSelective(required, info.required)
+		exact = info.exact
+	}
+	if complete {
+		return literalInfo{exact: exact}
+	}
+	return literalInfo{required: moreSelective(required, literalInfo{exact: exact}.best())}
+}
+
+// alternateLiterals combines the branches of an alternation: any of them may match, so all of them need to provide literals.
+func alternateLiterals(subs []*syntax.Regexp) literalInfo {
+	exact := []string{}
+	required := []string{}
+	for _, sub := range subs {
+		info := literalsOf(sub)
+		if nil != exact && nil != info.exact && len(exact)+len(info.exact) <= maxExactLiterals {
+			exact = union(exact, info.exact)
+		} else {
+			exact = nil
+		}
+		if nil != required {
+			if best := info.best(); nil != best {
+				required = union(required, best)
+			} else {
+				required = nil
+			}
+		}
+	}
+	return literalInfo{exact: exact, required: required}
+}
+
+// charClassLiterals expands small character classes, such as [;,] or [0-9], into the strings they can match.
+func charClassLiterals(ranges []rune) literalInfo {
+	count := 0
+	for i := 0; i < len(ranges); i += 2 {
+		count += int(ranges[i+1]-ranges[i]) + 1
+		if count > 2*maxCharClassRunes {
+			return literalInfo{}
+		}
+	}
+	literals := []string{}
+	for i := 0; i < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1]; r++ {
+			literal, ok := foldLiteral([]rune{r})
+			if !ok {
+				return literalInfo{}
+			}
+			literals = union(literals, []string{literal})
+		}
+	}
+	if len(literals) > maxCharClassRunes {
+		return literalInfo{}
+	}
+	return literalInfo{exact: literals}
+}
+
+// foldLiteral lower cases a literal the same way the User-Agent is, giving up on runes
+// which may have case folds strings.ToLower doesn't agree with.
+func foldLiteral(runes []rune) (string, bool) {
+	literal := foldUserAgent(string(runes))
+	for i := 0; i < len(literal); i++ {
+		if literal[i] >= 0x80 {
+			return "", false
+		}
+	}
+	return literal, true
+}
+
+func cross(prefixes, suffixes []string) []string {
+	literals := make([]string, 0, len(prefixes)*len(suffixes))
+	for _, prefix := range prefixes {
+		for _, suffix := range suffixes {
+			literals = append(literals, prefix+suffix)
+		}
+	}
+	return union(literals, nil)
+}
+
+// union returns the sorted set of the strings of both lists.
+func union(a, b []string) []string {
+	literals := append(append([]string{}, a...), b...)
+	sort.Strings(literals)
+	j := 0
+	for i, literal := range literals {
+		if 0 == i || literal != literals[j-1] {
+			literals[j] = literal
+			j++
+		}
+	}
+	return literals[:j]
+}
+
+// moreSelective picks the requirement least likely to be met by chance: the one with the longest
+// shortest string, or the one with the fewest strings when they are equal.
+func moreSelective(a, b []string) []string {
+	if nil == a {
+		return b
+	}
+	if nil == b {
+		return a
+	}
+	if shortest(b) > shortest(a) || (shortest(b) == shortest(a) && len(b) < len(a)) {
+		return b
+	}
+	return a
+}
+
+func shortest(literals []string) int {
+	min := -1
+	for _, literal := range literals {
+		if -1 == min || len(literal) < min {
+			min = len(literal)
+		}
+	}
+	return min
+}
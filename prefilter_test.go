@@ -0,0 +1,56 @@
+package mobiledetect
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequiredLiterals(t *testing.T) {
+	data := []struct {
+		rule     string
+		literals []string
+	}{
+		{`\biPhone\b|\biPod\b`, []string{`iphone`, `ipod`}},
+		{`BlackBerry|\bBB10\b|rim[0-9]+`, []string{`bb10`, `blackberry`, `rim`}},
+		{`Android.*Mobile`, []string{`android`}},
+		{`Nexus (7|9)`, []string{`nexus 7`, `nexus 9`}},
+		{`GT-I9300|GT-I9100`, []string{`gt-i9100`, `gt-i9300`}},
+		{`Dell[;]? (Streak|Aero)`, []string{`dell aero`, `dell streak`, `dell; aero`, `dell; streak`}},
+		{`Mobile|Android`, []string{`android`, `mobile`}},
+		{`(Xoom|MZ[0-9]+)?Android`, []string{`android`}},
+		{`\b[a-z]{2}\b`, nil},
+		{`Kindle|(Android|iPhone)?`, nil},
+		{`Kindle|.*`, nil},
+	}
+	for _, d := range data {
+		literals := requiredLiterals(d.rule)
+		if !reflect.DeepEqual(d.literals, literals) {
+			t.Errorf("Rule %s expected literals %q got %q", d.rule, d.literals, literals)
+		}
+	}
+}
+
+func TestPrefilterCandidates(t *testing.T) {
+	p := newPrefilter([][]string{
+		{`nexus 7`, `nexus 9`},
+		{`xus`},
+		nil,
+		{`droid`, `android`},
+		{`samsung`},
+	})
+	data := []struct {
+		userAgent  string
+		candidates []bool
+	}{
+		{`Mozilla/5.0 (Linux; Android 4.4.2; Nexus 7)`, []bool{true, true, true, true, false}},
+		{`Mozilla/5.0 (Linux; ANDROID 4.4.2; NEXUS 9)`, []bool{true, true, true, true, false}},
+		{`Mozilla/5.0 (Windows NT 6.1; rv:40.0) Gecko/20100101 Firefox/40.0`, []bool{false, false, true, false, false}},
+		{"Mozilla/5.0 (Linux; Nexus 5; \u017Famsung)", []bool{false, true, true, false, true}},
+	}
+	for _, d := range data {
+		candidates := p.candidates(d.userAgent)
+		if !reflect.DeepEqual(d.candidates, candidates) {
+			t.Errorf("For userAgent %s expected candidates %v got %v", d.userAgent, d.candidates, candidates)
+		}
+	}
+}
@@ -2,8 +2,10 @@ package mobiledetect
 
 import (
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 const (
@@ -205,6 +207,11 @@ type properties struct {
 	cache map[string]*regexp.Regexp
 }
 
+var (
+	sharedProperties     *properties
+	sharedPropertiesOnce sync.Once
+)
+
 func newProperties() *properties {
 	p := &properties{}
 	p.cache = make(map[string]*regexp.Regexp)
@@ -212,27 +219,39 @@ func newProperties() *properties {
 	return p
 }
 
+// defaultProperties returns properties shared by all MobileDetect objects.
+// Every pattern is compiled up front, so the cache is only read afterwards and safe for concurrent use.
+func defaultProperties() *properties {
+	sharedPropertiesOnce.Do(func() {
+		sharedProperties = newProperties()
+	})
+	return sharedProperties
+}
+
 func (p *properties) preCompile() {
 	for _, property := range props {
 		for _, pattern := range property {
-			p.compiledRegexByPattern(pattern)
+			propertyPattern := propertyRegex(pattern)
+			p.cache[propertyPattern] = regexp.MustCompile(propertyPattern)
 		}
 	}
 }
 
+func propertyRegex(propertyMatchString string) string {
+	return `(?is)` + strings.Replace(propertyMatchString, `[VER]`, verRegex, -1)
+}
+
 func (p *properties) compiledRegexByPattern(propertyPattern string) *regexp.Regexp {
-	re, ok := p.cache[propertyPattern]
-	if !ok {
-		p.cache[propertyPattern] = regexp.MustCompile(propertyPattern)
+	if re, ok := p.cache[propertyPattern]; ok {
+		return re
 	}
-	re = p.cache[propertyPattern]
-	return re
+	return regexp.MustCompile(propertyPattern)
 }
 
 func (p *properties) version(propertyVal int, userAgent string) string {
-	if len(props) >= propertyVal {
+	if 0 <= propertyVal && propertyVal < len(props) {
 		for _, propertyMatchString := range props[propertyVal] {
-			propertyPattern := `(?is)` + strings.Replace(string(propertyMatchString), `[VER]`, verRegex, -1)
+			propertyPattern := propertyRegex(propertyMatchString)
 
 			// Escape the special character which is the delimiter.
 			//propertyPattern = strings.Replace(propertyPattern, `/`, `\/`, -1)
@@ -248,6 +267,16 @@ func (p *properties) version(propertyVal int, userAgent string) string {
 	return ""
 }
 
+// PropertyNames returns the names accepted by MobileDetect.Version, sorted.
+func PropertyNames() []string {
+	names := make([]string, 0, len(propertiesNameToVal))
+	for name := range propertiesNameToVal {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (p *properties) nameToKey(propertyName string) int {
 	propertyName = strings.ToLower(propertyName)
 	propertyVal, ok := propertiesNameToVal[propertyName]
@@ -257,26 +286,8 @@ func (p *properties) nameToKey(propertyName string) int {
 	return propertyVal
 }
 
-func (p *properties) versionByName(propertyName, userAgent string) string {
-	if "" != propertyName {
-		propertyVal := p.nameToKey(propertyName)
-		if -1 != propertyVal {
-			return p.version(propertyVal, userAgent)
-		}
-	}
-	return ""
-}
-
-func (p *properties) versionFloatName(propertyName, userAgent string) float64 {
-	propertyVal := p.nameToKey(propertyName)
-	if -1 != propertyVal {
-		return p.versionFloat(propertyVal, userAgent)
-	}
-	return 0.0
-}
-
-func (p *properties) versionFloat(propertyVal int, userAgent string) float64 {
-	version := p.version(propertyVal, userAgent)
+// versionFloat turns a version such as 6.0.0.448 into a number good for comparison, 6.00448.
+func versionFloat(version string) float64 {
 	replacer := strings.NewReplacer(`_`, `.`, `/`, `.`)
 	version = replacer.Replace(version)
 
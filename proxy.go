@@ -0,0 +1,83 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+// ProxyHeaders names the request headers Director sends upstream. Headers without a name are not sent.
+type ProxyHeaders struct {
+	// Device holds the class of the device: mobile, tablet or desktop.
+	Device         string
+	OS             string
+	OSVersion      string
+	Browser        string
+	BrowserVersion string
+	Grade          string
+}
+
+// DefaultProxyHeaders are the usual names of the headers sent upstream.
+var DefaultProxyHeaders = ProxyHeaders{
+	Device:         "X-Device-Type",
+	OS:             "X-Device-OS",
+	OSVersion:      "X-Device-OS-Version",
+	Browser:        "X-Device-Browser",
+	BrowserVersion: "X-Device-Browser-Version",
+	Grade:          "X-Device-Grade",
+}
+
+// Director wraps the Director of an httputil.ReverseProxy so that upstream requests tell backends what the
+// device is, in the given headers. Copies of these headers sent by the client are always removed, so that
+// backends can trust them; headers with an unknown value, such as the OS of a desktop, are left out.
+// The detection runs once per request: the result stored by Middleware, or Handler, is used when there is one,
+// along with the device class it may have forced (see WithOverride), unless it lacks the details (see WithDetails).
+func Director(director func(*http.Request), headers ProxyHeaders, opts ...Option) func(*http.Request) {
+	o := newOptions(nil, append([]Option{WithDetails(headers.details())}, opts...))
+	return func(r *http.Request) {
+		if nil != director {
+			director(r)
+		}
+		res, ok := FromContext(r.Context())
+		if !ok {
+			_, res = o.detect(r)
+		} else if o.details {
+			res = o.detailed(r, res)
+		}
+		headers.set(r.Header, res)
+	}
+}
+
+// details tells whether any header needs the details of the results, see WithDetails.
+func (headers *ProxyHeaders) details() bool {
+	return "" != headers.OS || "" != headers.OSVersion || "" != headers.Browser || "" != headers.BrowserVersion || "" != headers.Grade
+}
+
+// NewReverseProxy returns an httputil.NewSingleHostReverseProxy for target which sends the device headers upstream, see Director.
+func NewReverseProxy(target *url.URL, headers ProxyHeaders, opts ...Option) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Director = Director(proxy.Director, headers, opts...)
+	return proxy
+}
+
+// set replaces the device headers of a request with the values found by the detection.
+func (headers *ProxyHeaders) set(header http.Header, res *Result) {
+	values := []struct{ name, value string }{
+		{headers.Device, strings.ToLower(res.Device())},
+		{headers.OS, res.OS},
+		{headers.OSVersion, res.OSVersion},
+		{headers.Browser, res.Browser},
+		{headers.BrowserVersion, res.BrowserVersion},
+		{headers.Grade, res.Grade},
+	}
+	for _, v := range values {
+		if "" == v.name {
+			continue
+		}
+		header.Del(v.name)
+		if "" != v.value {
+			header.Set(v.name, v.value)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package mobiledetect
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// echoHeaders is a backend answering with the headers it got.
+func echoHeaders() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(r.Header)
+	}))
+}
+
+func proxiedHeaders(t *testing.T, h http.Handler, r *http.Request) http.Header {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	var header http.Header
+	if err := json.NewDecoder(w.Body).Decode(&header); nil != err {
+		t.Fatal(err)
+	}
+	return header
+}
+
+func TestReverseProxy(t *testing.T) {
+	backend := echoHeaders()
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+	proxy := NewReverseProxy(target, DefaultProxyHeaders)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	r.Header.Set("X-Device-Type", "desktop")
+	r.Header.Set("X-Device-Browser", "spoofed")
+	header := proxiedHeaders(t, proxy, r)
+	expected := map[string]string{
+		"X-Device-Type":            "mobile",
+		"X-Device-Os":              "iOS",
+		"X-Device-Os-Version":      "6_0_1",
+		"X-Device-Browser":         "Safari",
+		"X-Device-Browser-Version": "6.0",
+		"X-Device-Grade":           "A",
+	}
+	for name, value := range expected {
+		if values := header[name]; 1 != len(values) || value != values[0] {
+			t.Errorf("%s: expected %q got %v", name, value, values)
+		}
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", desktopUserAgent)
+	r.Header.Set("X-Device-OS", "iOS")
+	header = proxiedHeaders(t, proxy, r)
+	if _, ok := header["X-Device-Os"]; ok {
+		t.Error("Client supplied headers should be removed")
+	}
+	if "desktop" != header.Get("X-Device-Type") {
+		t.Errorf("Expected a desktop, got %q", header.Get("X-Device-Type"))
+	}
+}
+
+func TestDirectorUsesContext(t *testing.T) {
+	backend := echoHeaders()
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+	proxy := NewReverseProxy(target, ProxyHeaders{Device: "X-Class"})
+	h := Middleware(WithOverride(overrideConfig))(proxy)
+
+	r := httptest.NewRequest("GET", "/?device=tablet", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	header := proxiedHeaders(t, h, r)
+	if "tablet" != header.Get("X-Class") {
+		t.Errorf("The forced class should be sent upstream, got %q", header.Get("X-Class"))
+	}
+	if "" != header.Get("X-Device-Type") {
+		t.Error("Only the configured headers should be sent")
+	}
+}
+
+func TestDirectorDetails(t *testing.T) {
+	backend := echoHeaders()
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+	// Middleware does not fill in the details the proxy headers need
+	h := Middleware(WithOverride(overrideConfig))(NewReverseProxy(target, DefaultProxyHeaders))
+
+	r := httptest.NewRequest("GET", "/?device=tablet", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	header := proxiedHeaders(t, h, r)
+	if "tablet" != header.Get("X-Device-Type") || "iOS" != header.Get("X-Device-OS") || "A" != header.Get("X-Device-Grade") {
+		t.Errorf("Expected the forced class along with the details, got %v", header)
+	}
+}
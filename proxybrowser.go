@@ -0,0 +1,62 @@
+package mobiledetect
+
+import "strings"
+
+// The proxy modes ProxyMode reports: the browser renders pages on its servers and sends the device a compressed
+// rendition, with little or no JavaScript running on the device.
+const (
+	// Opera Mini in extreme mode; Opera Mini 8 and later in high savings mode browse like Chrome.
+	PROXY_MODE_OPERA_MINI = "OperaMini"
+	// UC Browser in cloud (speed) mode, and UC Mini.
+	PROXY_MODE_UC_CLOUD = "UCCloud"
+	// Puffin, which always renders in the cloud.
+	PROXY_MODE_PUFFIN = "Puffin"
+)
+
+// proxyBrowser tells a proxy mode apart by the User-Agent, or by headers only sent by its proxies.
+type proxyBrowser struct {
+	mode    string
+	pattern string
+	headers []string
+}
+
+// The proxy browsers, by priority: UC Browser may mention Opera Mini.
+var proxyBrowsers = []proxyBrowser{
+	{PROXY_MODE_OPERA_MINI, `Opera Mini/|OPiOS/`, []string{"HTTP_X_OPERAMINI_FEATURES", "HTTP_X_OPERAMINI_PHONE_UA"}},
+	{PROXY_MODE_UC_CLOUD, `UCWEB|UC ?Mini|\bU2/`, nil},
+	{PROXY_MODE_PUFFIN, `Puffin/`, nil},
+}
+
+// The headers of proxyBrowsers, which are part of the cache key.
+func proxyBrowserHeaders() []string {
+	var names []string
+	for _, p := range proxyBrowsers {
+		names = append(names, p.headers...)
+	}
+	return names
+}
+
+// IsProxyBrowser tells whether the page is rendered by a proxy browser, such as Opera Mini, rather than
+// by the device itself: such browsers are better served lightweight markup. See ProxyMode.
+func (md *MobileDetect) IsProxyBrowser() bool {
+	return "" != md.ProxyMode()
+}
+
+// ProxyMode returns the proxy browser rendering the page, PROXY_MODE_OPERA_MINI, PROXY_MODE_UC_CLOUD
+// or PROXY_MODE_PUFFIN, or nothing. Besides the User-Agent, it looks at the one of the proxy when the
+// device was found behind it, see WithForwardedUserAgent, and at the X-OperaMini-Features and
+// X-OperaMini-Phone-UA headers when they are trusted, see WithTrustedHeaders.
+func (md *MobileDetect) ProxyMode() string {
+	for _, p := range proxyBrowsers {
+		re := compiledPattern(`(?is)` + p.pattern)
+		if re.MatchString(md.userAgent) || ("" != md.proxyUserAgent && re.MatchString(md.proxyUserAgent)) {
+			return p.mode
+		}
+		for _, name := range p.headers {
+			if value, ok := md.httpHeader(name); ok && "" != strings.TrimSpace(value) {
+				return p.mode
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+package mobiledetect
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyMode(t *testing.T) {
+	data := []struct {
+		userAgent string
+		headers   map[string]string
+		expected  string
+	}{
+		{`Opera/9.80 (Android; Opera Mini/7.0.29952/28.2647; U; ru) Presto/2.8.119 Version/11.10`, nil, PROXY_MODE_OPERA_MINI},
+		{`Mozilla/5.0 (iPhone; CPU iPhone OS 8_1 like Mac OS X) AppleWebKit/600.1.4 (KHTML, like Gecko) OPiOS/9.1.0.86723 Mobile/12B411 Safari/9537.53`, nil, PROXY_MODE_OPERA_MINI},
+		{`UCWEB/2.0 (Linux; U; Opera Mini/7.1.32052/30.3697; en-US; IdeaTabA1000-G) U2/1.0.0 UCBrowser/9.2.0.419 Mobile`, nil, PROXY_MODE_OPERA_MINI},
+		{`Nokia200/2.0 (12.04) Profile/MIDP-2.1 Configuration/CLDC-1.1 UCWEB/2.0 (Java; U; MIDP-2.0; en-US; nokia200) U2/1.0.0 UCBrowser/8.9.0.251 U2/1.0.0 Mobile UNTRUSTED/1.0`, nil, PROXY_MODE_UC_CLOUD},
+		{`Mozilla/5.0 (Linux; U; Android 4.2.2; en-US; Micromax A116 Build/JDQ39) AppleWebKit/534.31 (KHTML, like Gecko) UCBrowser/9.3.0.321 U3/0.8.0 Mobile Safari/534.31`, nil, ""},
+		{`Mozilla/5.0 (Linux; Android 4.1.2; GT-I9100 Build/JZO54K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/30.0.0.0 Mobile Safari/537.36 Puffin/4.0.4.1208AP`, nil, PROXY_MODE_PUFFIN},
+		{`Mozilla/5.0 (Linux; Android 6.0; Nexus 5) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/45.0.2454.94 Mobile Safari/537.36 OPR/32.0.1953.96596`, nil, ""},
+		{iPhoneUserAgent, map[string]string{"X-OperaMini-Features": "advanced, file_system, camera, touch"}, PROXY_MODE_OPERA_MINI},
+		{iPhoneUserAgent, map[string]string{"X-OperaMini-Phone-UA": iPhoneUserAgent}, PROXY_MODE_OPERA_MINI},
+		{iPhoneUserAgent, nil, ""},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", d.userAgent)
+		for name, value := range d.headers {
+			r.Header.Set(name, value)
+		}
+		md := NewMobileDetect(r, nil, WithTrustedHeaders(DefaultTrustedHeaders()...))
+		if d.expected != md.ProxyMode() || ("" != d.expected) != md.IsProxyBrowser() {
+			t.Errorf("Expected %q got %q for %s %v", d.expected, md.ProxyMode(), d.userAgent, d.headers)
+		}
+		if res := md.Result(); d.expected != res.ProxyMode || ("" != d.expected) != res.IsProxyBrowser() {
+			t.Errorf("Expected the result to have %q got %q", d.expected, res.ProxyMode)
+		}
+	}
+}
+
+func TestProxyModeHeaders(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	r.Header.Set("X-OperaMini-Features", "advanced")
+	if md := NewMobileDetect(r, nil, WithTrustedHeaders()); md.IsProxyBrowser() {
+		t.Errorf("Expected the untrusted header to be ignored")
+	}
+
+	cache := NewCache(10)
+	detector := NewDetector(WithCache(cache), WithTrustedHeaders(DefaultTrustedHeaders()...))
+	if res := detector.Detect(r); !res.IsProxyBrowser() {
+		t.Errorf("Expected a proxy browser")
+	}
+	r.Header.Del("X-OperaMini-Features")
+	if res := detector.Detect(r); res.IsProxyBrowser() {
+		t.Errorf("Expected the header to be part of the cache key")
+	}
+}
+
+func TestProxyModeForwarded(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", operaMiniUserAgent)
+	r.Header.Set("Device-Stock-UA", iPadUserAgent)
+	res := NewDetector(WithTrustedHeaders(), WithForwardedUserAgent(ForwardedUserAgentConfig{})).Detect(r)
+	if !res.Tablet || PROXY_MODE_OPERA_MINI != res.ProxyMode {
+		t.Errorf("Expected an iPad behind Opera Mini got %+v", res)
+	}
+}
@@ -0,0 +1,129 @@
+package mobiledetect
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// The query parameter and cookie letting users view the full site, unless RedirectConfig names others.
+const REDIRECT_OPT_OUT = "fullsite"
+
+// RedirectConfig describes where Redirect sends mobile devices.
+type RedirectConfig struct {
+	// Host of the mobile site, e.g. m.example.com. When empty, the mobile site is on the same host under PathPrefix.
+	Host string
+	// PathPrefix of the mobile site, e.g. /m. The path of the request is appended to it.
+	PathPrefix string
+	// Scheme of the mobile site, by default the one of the request.
+	Scheme string
+	// Tablets are redirected as well when true, otherwise only phones are.
+	Tablets bool
+	// OptOutParam is the query parameter asking for the full site: ?fullsite=1 stops redirecting the user,
+	// remembering the choice in the OptOutCookie, and ?fullsite=0 starts again.
+	OptOutParam  string
+	OptOutCookie string
+	// Code is the status of the redirect, http.StatusFound by default.
+	Code int
+}
+
+// Redirect sends phones, and tablets when asked to, to the mobile site, keeping the path and query of GET and HEAD
+// requests; requests made with other methods, such as form posts, are never redirected. Requests already for the mobile site, or from users who opted out, go to the next handler untouched, along with
+// the detection result (see FromContext). Responses vary on the User-Agent, the opt-out cookie
+// and the other inputs of the detection, see WithVary.
+func Redirect(config RedirectConfig, opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(nil, opts)
+	if "" == config.OptOutParam {
+		config.OptOutParam = REDIRECT_OPT_OUT
+	}
+	if "" == config.OptOutCookie {
+		config.OptOutCookie = REDIRECT_OPT_OUT
+	}
+	if 0 == config.Code {
+		config.Code = http.StatusFound
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.isMobileSite(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			addVary(w.Header(), "User-Agent", "Cookie")
+
+			res, ok := FromContext(r.Context())
+			if !ok {
+				_, res = o.detect(r)
+				res = o.applyOverride(w, r, res)
+				o.setResponseHeaders(w, res)
+				r = r.WithContext(o.newContext(r.Context(), res))
+				o.setBucketHeader(r, res)
+			}
+			// a redirect would lose the body of other requests
+			if !isSafeMethod(r.Method) || config.optedOut(w, r) || !(res.Mobile && (!res.Tablet || config.Tablets)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Redirect(w, r, config.location(r), config.Code)
+		})
+	}
+}
+
+// isMobileSite tells whether the request is already for the mobile site, so it must not be redirected again.
+func (config *RedirectConfig) isMobileSite(r *http.Request) bool {
+	if "" != config.Host && !strings.EqualFold(hostname(config.Host), hostname(r.Host)) {
+		return false
+	}
+	prefix := strings.TrimSuffix(config.PathPrefix, "/")
+	if "" == prefix {
+		// without a prefix, nor a host, there is no mobile site to go to
+		return true
+	}
+	return r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/")
+}
+
+// optedOut tells whether the user asked for the full site, now or before, and keeps the cookie up to date.
+func (config *RedirectConfig) optedOut(w http.ResponseWriter, r *http.Request) bool {
+	if values, ok := r.URL.Query()[config.OptOutParam]; ok {
+		optOut := 0 == len(values) || "0" != values[0]
+		cookie := &http.Cookie{Name: config.OptOutCookie, Value: "1", Path: "/"}
+		if !optOut {
+			cookie.MaxAge = -1
+		}
+		http.SetCookie(w, cookie)
+		addCacheControl(w.Header(), "private")
+		return optOut
+	}
+	cookie, err := r.Cookie(config.OptOutCookie)
+	return nil == err && "1" == cookie.Value
+}
+
+func (config *RedirectConfig) location(r *http.Request) string {
+	scheme := config.Scheme
+	if "" == scheme {
+		scheme = "http"
+		if nil != r.TLS {
+			scheme = "https"
+		}
+	}
+	host := config.Host
+	if "" == host {
+		host = r.Host
+	}
+	location := scheme + "://" + host + strings.TrimSuffix(config.PathPrefix, "/") + r.URL.EscapedPath()
+	if "" != r.URL.RawQuery {
+		location += "?" + r.URL.RawQuery
+	}
+	return location
+}
+
+func isSafeMethod(method string) bool {
+	return http.MethodGet == method || http.MethodHead == method
+}
+
+// hostname strips the port, if any, from host.
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); nil == err {
+		return h
+	}
+	return host
+}
@@ -0,0 +1,112 @@
+package mobiledetect
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	iPhoneUserAgent = `Mozilla/5.0 (iPhone; CPU iPhone OS 6_0_1 like Mac OS X) AppleWebKit/536.26 (KHTML, like Gecko) Version/6.0 Mobile/10A523 Safari/8536.25`
+	iPadUserAgent   = `Mozilla/5.0 (iPad; CPU OS 5_1_1 like Mac OS X; en-us) AppleWebKit/534.46.0 (KHTML, like Gecko) CriOS/21.0.1180.80 Mobile/9B206 Safari/7534.48.3 (6FF046A0-1BC4-4E7D-8A9D-6BF17622A123)`
+)
+
+func serveRedirect(config RedirectConfig, userAgent, url string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	h := Redirect(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	r := httptest.NewRequest("GET", url, nil)
+	r.Header.Set("User-Agent", userAgent)
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestRedirect(t *testing.T) {
+	hostConfig := RedirectConfig{Host: "m.example.com"}
+	prefixConfig := RedirectConfig{PathPrefix: "/m/", Tablets: true, Code: http.StatusMovedPermanently}
+	data := []struct {
+		config    RedirectConfig
+		userAgent string
+		url       string
+		code      int
+		location  string
+	}{
+		{hostConfig, iPhoneUserAgent, "http://www.example.com/news?id=1", http.StatusFound, "http://m.example.com/news?id=1"},
+		{hostConfig, iPadUserAgent, "http://www.example.com/news?id=1", http.StatusOK, ""},
+		{hostConfig, desktopUserAgent, "http://www.example.com/news", http.StatusOK, ""},
+		{hostConfig, iPhoneUserAgent, "http://m.example.com:8080/news", http.StatusOK, ""},
+		{hostConfig, iPhoneUserAgent, "https://www.example.com/a%20b", http.StatusFound, "https://m.example.com/a%20b"},
+		{prefixConfig, iPadUserAgent, "http://www.example.com/news?id=1", http.StatusMovedPermanently, "http://www.example.com/m/news?id=1"},
+		{prefixConfig, iPhoneUserAgent, "http://www.example.com/m/news", http.StatusOK, ""},
+		{prefixConfig, iPhoneUserAgent, "http://www.example.com/m", http.StatusOK, ""},
+		{prefixConfig, iPhoneUserAgent, "http://www.example.com/mobile", http.StatusMovedPermanently, "http://www.example.com/m/mobile"},
+		{RedirectConfig{}, iPhoneUserAgent, "http://www.example.com/", http.StatusOK, ""},
+	}
+	for _, d := range data {
+		w := serveRedirect(d.config, d.userAgent, d.url)
+		if d.code != w.Code || d.location != w.Header().Get("Location") {
+			t.Errorf("%s: expected %d %s got %d %s", d.url, d.code, d.location, w.Code, w.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRedirectMethods(t *testing.T) {
+	config := RedirectConfig{Host: "m.example.com"}
+	for _, method := range []string{"POST", "PUT", "DELETE", "HEAD"} {
+		var body string
+		h := Redirect(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			body = string(b)
+			if _, ok := FromContext(r.Context()); !ok {
+				t.Errorf("%s: expected the detection result in the context", method)
+			}
+		}))
+		r := httptest.NewRequest(method, "http://www.example.com/checkout", strings.NewReader("item=1"))
+		r.Header.Set("User-Agent", iPhoneUserAgent)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if "HEAD" == method {
+			if http.StatusFound != w.Code || "http://m.example.com/checkout" != w.Header().Get("Location") {
+				t.Errorf("HEAD: expected a redirect got %d %s", w.Code, w.Header().Get("Location"))
+			}
+			continue
+		}
+		if http.StatusOK != w.Code || "" != w.Header().Get("Location") || "item=1" != body {
+			t.Errorf("%s: expected the request to reach the handler, got %d %s %q", method, w.Code, w.Header().Get("Location"), body)
+		}
+	}
+}
+
+func TestRedirectOptOut(t *testing.T) {
+	config := RedirectConfig{Host: "m.example.com"}
+	w := serveRedirect(config, iPhoneUserAgent, "http://www.example.com/?fullsite=1")
+	if http.StatusOK != w.Code {
+		t.Errorf("Opting out should not redirect, got %d", w.Code)
+	}
+	cookies := w.Result().Cookies()
+	if 1 != len(cookies) || REDIRECT_OPT_OUT != cookies[0].Name || "1" != cookies[0].Value {
+		t.Fatalf("Opting out should set a cookie, got %v", cookies)
+	}
+	if w = serveRedirect(config, iPhoneUserAgent, "http://www.example.com/", cookies[0]); http.StatusOK != w.Code {
+		t.Errorf("The opt out cookie should prevent the redirect, got %d", w.Code)
+	}
+	w = serveRedirect(config, iPhoneUserAgent, "http://www.example.com/?fullsite=0", cookies[0])
+	if http.StatusFound != w.Code {
+		t.Errorf("Opting in again should redirect, got %d", w.Code)
+	}
+	if cookies = w.Result().Cookies(); 1 != len(cookies) || cookies[0].MaxAge >= 0 {
+		t.Errorf("Opting in again should delete the cookie, got %v", cookies)
+	}
+	if vary := w.Header().Values("Vary"); !hasToken(vary, "User-Agent") || !hasToken(vary, "Cookie") || hasToken(vary, "X-Wap-Profile") {
+		t.Errorf("Unexpected Vary %v", vary)
+	}
+	if "private" != w.Header().Get("Cache-Control") {
+		t.Errorf("Setting the opt out cookie should make the response private, got %q", w.Header().Get("Cache-Control"))
+	}
+}
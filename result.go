@@ -0,0 +1,117 @@
+package mobiledetect
+
+const (
+	DEVICE_MOBILE  = "Mobile"
+	DEVICE_TABLET  = "Tablet"
+	DEVICE_DESKTOP = "Desktop"
+)
+
+// Result is a snapshot of everything MobileDetect found out about a request.
+// Results may be shared through a Cache and must not be modified.
+//
+// Grade, OS, OSVersion, Browser, BrowserVersion and ProxyMode take most of the time of the detection: Handler,
+// HandlerMux, Middleware and Redirect only fill them in when asked to with WithDetails, or by the options
+// relying on them.
+type Result struct {
+	UserAgent string
+	Mobile    bool
+	Tablet    bool
+	Grade     string
+	// PhoneTier is PHONE_TIER_SMARTPHONE or PHONE_TIER_FEATURE for phones, see MobileDetect.PhoneTier
+	PhoneTier string
+	// OS and Browser are empty when unknown, as for desktops; see MobileDetect.OS and MobileDetect.Browser
+	OS             string
+	OSVersion      string
+	Browser        string
+	BrowserVersion string
+	// Keys of all the rules matching the User-Agent, in ascending order
+	Keys []int
+	// Overridden is true when Mobile and Tablet were forced by the user, see WithOverride;
+	// Detected then holds the class of the device found by the detection.
+	Overridden bool
+	Detected   string
+	// ProxyUserAgent is the User-Agent of the proxy when the one of the device was forwarded in the header
+	// ForwardedBy, see WithForwardedUserAgent.
+	ProxyUserAgent string
+	ForwardedBy    string
+	// ProxyMode is the proxy browser rendering the page, if any, see MobileDetect.ProxyMode
+	ProxyMode string
+	// Carrier is the operator or gateway the request went through, and CarrierDeviceID the identifier
+	// of the device it sent, if any; see MobileDetect.Carrier
+	Carrier         string
+	CarrierDeviceID string
+
+	// whether the fields of WithDetails were filled in
+	detailed bool
+}
+
+// Result runs the full detection and returns its outcome.
+func (md *MobileDetect) Result() *Result {
+	res := md.basicResult()
+	md.addDetails(res)
+	return res
+}
+
+// basicResult returns the outcome of the detection, but for the fields WithDetails asks for.
+func (md *MobileDetect) basicResult() *Result {
+	res := &Result{
+		UserAgent: md.userAgent,
+		Mobile:    md.IsMobile(),
+		Tablet:    md.IsTablet(),
+		PhoneTier: md.PhoneTier(),
+
+		ProxyUserAgent: md.proxyUserAgent,
+		ForwardedBy:    md.forwardedBy,
+	}
+	res.Carrier, res.CarrierDeviceID = md.Carrier()
+	for key, matched := range md.matches() {
+		if matched {
+			res.Keys = append(res.Keys, key)
+		}
+	}
+	return res
+}
+
+// addDetails fills in the grade, software and proxy mode of res.
+func (md *MobileDetect) addDetails(res *Result) {
+	res.Grade = md.MobileGrade()
+	res.OS, res.OSVersion = md.OS()
+	res.Browser, res.BrowserVersion = md.Browser()
+	res.ProxyMode = md.ProxyMode()
+	res.detailed = true
+}
+
+// Device returns the class of the device, the same way HandlerMux names it.
+func (res *Result) Device() string {
+	if res.Tablet {
+		return DEVICE_TABLET
+	} else if res.Mobile {
+		return DEVICE_MOBILE
+	}
+	return DEVICE_DESKTOP
+}
+
+// IsBot tells whether the User-Agent is the one of a crawler, mobile or not.
+func (res *Result) IsBot() bool {
+	return res.IsKey(BOT) || res.IsKey(MOBILEBOT)
+}
+
+// IsFeaturePhone tells whether the device is a feature phone rather than a smartphone.
+func (res *Result) IsFeaturePhone() bool {
+	return PHONE_TIER_FEATURE == res.PhoneTier
+}
+
+// IsProxyBrowser tells whether the page is rendered by a proxy browser, such as Opera Mini.
+func (res *Result) IsProxyBrowser() bool {
+	return "" != res.ProxyMode
+}
+
+// IsKey tells whether the rule matched the User-Agent.
+func (res *Result) IsKey(key int) bool {
+	for _, k := range res.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package mobiledetect
+
+import "testing"
+
+func TestResult(t *testing.T) {
+	detect := NewMobileDetect(httpRequest, nil)
+	detect.SetUserAgent(`Mozilla/5.0 (iPad; CPU OS 5_1_1 like Mac OS X; en-us) AppleWebKit/534.46.0 (KHTML, like Gecko) CriOS/21.0.1180.80 Mobile/9B206 Safari/7534.48.3 (6FF046A0-1BC4-4E7D-8A9D-6BF17622A123)`)
+	res := detect.Result()
+	if !res.Mobile || !res.Tablet || DEVICE_TABLET != res.Device() {
+		t.Errorf("Expected a tablet, got %+v", res)
+	}
+	if MOBILE_GRADE_A != res.Grade {
+		t.Errorf("Expected grade A, got %s", res.Grade)
+	}
+	if !res.IsKey(IPAD) || !res.IsKey(IOS) || res.IsKey(IPHONE) {
+		t.Errorf("Unexpected keys %v", res.Keys)
+	}
+
+	detect.SetUserAgent("Mozilla/5.0 (Windows NT 6.1; rv:40.0) Gecko/20100101 Firefox/40.0")
+	res = detect.Result()
+	if DEVICE_DESKTOP != res.Device() || 0 != len(res.Keys) {
+		t.Errorf("Expected a desktop, got %+v", res)
+	}
+}
@@ -0,0 +1,113 @@
+package mobiledetect
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Router dispatches requests to the handler registered for their path and for the class of their device.
+// Paths are matched the way http.ServeMux matches them. When no handler was registered for the class
+// of the device, the fallbacks of the class are tried in order: by default tablets fall back to mobile
+// then desktop handlers, and phones to desktop ones. Routes may be added while the router is serving.
+type Router struct {
+	mux       *http.ServeMux
+	handler   http.Handler
+	mu        sync.RWMutex
+	routes    map[string]*routeSet
+	fallbacks map[string][]string
+}
+
+// routeSet holds the handlers registered for a single pattern, by device class.
+type routeSet struct {
+	router   *Router
+	handlers map[string]http.Handler
+}
+
+// NewRouter creates a Router detecting devices with the given options, the same way Middleware does.
+func NewRouter(opts ...Option) *Router {
+	rt := &Router{
+		mux:    http.NewServeMux(),
+		routes: make(map[string]*routeSet),
+		fallbacks: map[string][]string{
+			DEVICE_TABLET: {DEVICE_MOBILE, DEVICE_DESKTOP},
+			DEVICE_MOBILE: {DEVICE_DESKTOP},
+		},
+	}
+	rt.handler = newOptions(nil, opts).middleware(rt.mux)
+	return rt
+}
+
+// Handle registers the handler for the pattern and the device class, one of DEVICE_MOBILE, DEVICE_TABLET
+// and DEVICE_DESKTOP. Like http.ServeMux, it panics when the pattern is invalid or already has a handler for the class.
+func (rt *Router) Handle(device, pattern string, handler http.Handler) {
+	validDevice(device)
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	set, ok := rt.routes[pattern]
+	if !ok {
+		set = &routeSet{router: rt, handlers: make(map[string]http.Handler)}
+		rt.mux.Handle(pattern, set)
+		rt.routes[pattern] = set
+	}
+	if _, ok := set.handlers[device]; ok {
+		panic(fmt.Sprintf("mobiledetect: multiple registrations for %s %s", device, pattern))
+	}
+	set.handlers[device] = handler
+}
+
+// HandleFunc registers the handler function for the pattern and the device class, see Handle.
+func (rt *Router) HandleFunc(device, pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	rt.Handle(device, pattern, http.HandlerFunc(handler))
+}
+
+// Fallback replaces the classes tried, in order, when no handler was registered for the device class.
+// Without arguments, requests from such devices are not found.
+func (rt *Router) Fallback(device string, fallbacks ...string) {
+	validDevice(device)
+	for _, fallback := range fallbacks {
+		validDevice(fallback)
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.fallbacks[device] = fallbacks
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
+
+func (set *routeSet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	device := DEVICE_DESKTOP
+	if res, ok := FromContext(r.Context()); ok {
+		device = res.Device()
+	}
+	if handler := set.lookup(device); nil != handler {
+		handler.ServeHTTP(w, r)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// lookup returns the handler for the device class, or for the first of its fallbacks having one.
+func (set *routeSet) lookup(device string) http.Handler {
+	set.router.mu.RLock()
+	defer set.router.mu.RUnlock()
+	if handler, ok := set.handlers[device]; ok {
+		return handler
+	}
+	for _, fallback := range set.router.fallbacks[device] {
+		if handler, ok := set.handlers[fallback]; ok {
+			return handler
+		}
+	}
+	return nil
+}
+
+func validDevice(device string) {
+	switch device {
+	case DEVICE_MOBILE, DEVICE_TABLET, DEVICE_DESKTOP:
+		return
+	}
+	panic(fmt.Sprintf("mobiledetect: unknown device class %q", device))
+}
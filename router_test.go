@@ -0,0 +1,114 @@
+package mobiledetect
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func respond(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}
+}
+
+func serveRouter(rt *Router, userAgent, url string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", url, nil)
+	r.Header.Set("User-Agent", userAgent)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	return w
+}
+
+func TestRouter(t *testing.T) {
+	rt := NewRouter()
+	rt.Handle(DEVICE_DESKTOP, "/", respond("desktop home"))
+	rt.Handle(DEVICE_MOBILE, "/", respond("mobile home"))
+	rt.HandleFunc(DEVICE_DESKTOP, "/about", respond("desktop about"))
+	rt.HandleFunc(DEVICE_TABLET, "/app/", respond("tablet app"))
+
+	data := []struct {
+		userAgent, url string
+		code           int
+		body           string
+	}{
+		{desktopUserAgent, "/", http.StatusOK, "desktop home"},
+		{iPhoneUserAgent, "/", http.StatusOK, "mobile home"},
+		{iPadUserAgent, "/", http.StatusOK, "mobile home"},
+		{iPhoneUserAgent, "/about", http.StatusOK, "desktop about"},
+		{iPadUserAgent, "/about", http.StatusOK, "desktop about"},
+		{iPadUserAgent, "/app/x", http.StatusOK, "tablet app"},
+		{iPhoneUserAgent, "/app/x", http.StatusNotFound, ""},
+		{desktopUserAgent, "/app/x", http.StatusNotFound, ""},
+	}
+	for _, d := range data {
+		w := serveRouter(rt, d.userAgent, d.url)
+		if d.code != w.Code || (http.StatusOK == d.code && d.body != w.Body.String()) {
+			t.Errorf("%s %s: expected %d %q got %d %q", d.userAgent, d.url, d.code, d.body, w.Code, w.Body.String())
+		}
+	}
+
+	rt.Fallback(DEVICE_TABLET, DEVICE_DESKTOP)
+	if w := serveRouter(rt, iPadUserAgent, "/"); "desktop home" != w.Body.String() {
+		t.Errorf("Tablets should now fall back to desktop, got %q", w.Body.String())
+	}
+	rt.Fallback(DEVICE_MOBILE)
+	if w := serveRouter(rt, iPhoneUserAgent, "/about"); http.StatusNotFound != w.Code {
+		t.Errorf("Phones should not fall back anymore, got %d", w.Code)
+	}
+}
+
+func TestRouterOptions(t *testing.T) {
+	rt := NewRouter(WithOverride(overrideConfig), WithTrustedHeaders())
+	rt.Handle(DEVICE_DESKTOP, "/", respond("desktop"))
+	rt.Handle(DEVICE_MOBILE, "/", respond("mobile"))
+	w := serveRouter(rt, iPhoneUserAgent, "/?device=desktop")
+	if "desktop" != w.Body.String() {
+		t.Errorf("The forced class should be routed, got %q", w.Body.String())
+	}
+	if !hasToken(w.Header().Values("Vary"), "User-Agent") {
+		t.Error("Routed responses should vary on the User-Agent")
+	}
+}
+
+func TestRouterPanics(t *testing.T) {
+	data := []func(rt *Router){
+		func(rt *Router) { rt.Handle("Phone", "/", respond("")) },
+		func(rt *Router) { rt.Handle(DEVICE_MOBILE, "/", respond("")) },
+		func(rt *Router) { rt.Fallback(DEVICE_TABLET, "desktop") },
+	}
+	for idx, f := range data {
+		func() {
+			defer func() {
+				if nil == recover() {
+					t.Errorf("%d: expected a panic", idx)
+				}
+			}()
+			rt := NewRouter()
+			rt.Handle(DEVICE_MOBILE, "/", respond(""))
+			f(rt)
+		}()
+	}
+}
+
+func TestRouterConcurrent(t *testing.T) {
+	rt := NewRouter()
+	rt.Handle(DEVICE_DESKTOP, "/", respond("desktop"))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				userAgent := []string{desktopUserAgent, iPhoneUserAgent, iPadUserAgent}[j%3]
+				if w := serveRouter(rt, userAgent, "/"); http.StatusOK != w.Code {
+					t.Errorf("%s: unexpected status %d", userAgent, w.Code)
+				}
+			}
+			rt.Handle(DEVICE_MOBILE, fmt.Sprintf("/%d", i), respond("mobile"))
+		}(i)
+	}
+	wg.Wait()
+}
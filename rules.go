@@ -1,7 +1,16 @@
 package mobiledetect
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
 // Upstream Version: 2.8.29
 // https://github.com/serbanghita/Mobile-Detect/blob/2.8.29/Mobile_Detect.php
+const UPSTREAM_VERSION = "2.8.29"
 
 const (
 	IPHONE = iota
@@ -183,6 +192,10 @@ const (
 	NETFRONT
 	GENERICBROWSER
 	PALEMOON
+
+	BOT = iota
+	MOBILEBOT
+	TV
 )
 
 var (
@@ -691,6 +704,16 @@ var (
 		//PaleMoon:
 		`Android.*PaleMoon|Mobile.*PaleMoon`,
 	}
+	// utilities tell more about the client but take no part in IsMobile nor IsTablet.
+	// WebKit is left out: MobileGrade asks for it, and the grades expected by the tests were worked out without it
+	utilities = [...]string{
+		//BOT:
+		`Googlebot|facebookexternalhit|Google-AMPHTML|s~amp-validator|AdsBot-Google|Google Keyword Suggestion|Facebot|YandexBot|YandexMobileBot|bingbot|ia_archiver|AhrefsBot|Ezooms|GSLFbot|WBSearchBot|Twitterbot|TweetmemeBot|Twikle|PaperLiBot|Wotbox|UnwindFetchor|Exabot|MJ12bot|YandexImages|TurnitinBot|Pingdom|contentkingapp|AspiegelBot`,
+		//MOBILEBOT:
+		`Googlebot-Mobile|AdsBot-Google-Mobile|YahooSeeker/M1A1-R2D2`,
+		//TV:
+		`SonyDTV|HbbTV`, // experimental
+	}
 
 	nameToKey = map[string]int{
 		`iphone`:            IPHONE,
@@ -869,6 +892,9 @@ var (
 		`netfront`:          NETFRONT,
 		`genericbrowser`:    GENERICBROWSER,
 		`palemoon`:          PALEMOON,
+		`bot`:               BOT,
+		`mobilebot`:         MOBILEBOT,
+		`tv`:                TV,
 	}
 )
 
@@ -879,12 +905,18 @@ type rules struct {
 	tabletDevices    [len(tabletDevices)]string
 	operatingSystems [len(operatingSystems)]string
 	browsers         [len(browsers)]string
+	utilities        [len(utilities)]string
 	combined         []string
+	compileOnce      sync.Once
+	matcher          *matcher
+	noPrefilter      int32
+	fingerprintOnce  sync.Once
+	hash             string
 }
 
 // NewRules creates a object with all rules necessary to figure out a browser from a User Agent string
 func NewRules() *rules {
-	rules := &rules{namesKeys: nameToKey, phoneDevices: phoneDevices, tabletDevices: tabletDevices, operatingSystems: operatingSystems, browsers: browsers}
+	rules := &rules{namesKeys: nameToKey, phoneDevices: phoneDevices, tabletDevices: tabletDevices, operatingSystems: operatingSystems, browsers: browsers, utilities: utilities}
 	rules.setMobileDetectionRules(nameToKey)
 	return rules
 }
@@ -893,6 +925,79 @@ func (r *rules) mobileDetectionRules() []string {
 	return r.combined
 }
 
+// compiled returns the matcher of the rules, compiling it on first use.
+func (r *rules) compiled() *matcher {
+	r.compileOnce.Do(func() {
+		r.matcher = newMatcher(r)
+	})
+	return r.matcher
+}
+
+// SetPrefilter turns the literal prefilter on or off. It is on by default and should only be turned off
+// to debug a rule which doesn't match as expected; results are the same either way, only slower without it.
+func (r *rules) SetPrefilter(enabled bool) *rules {
+	var noPrefilter int32
+	if !enabled {
+		noPrefilter = 1
+	}
+	atomic.StoreInt32(&r.noPrefilter, noPrefilter)
+	return r
+}
+
+// match runs all the rules against the User-Agent.
+func (r *rules) match(userAgent string) matchSet {
+	return r.compiled().match(userAgent, 0 == atomic.LoadInt32(&r.noPrefilter))
+}
+
+// KeyName returns the name of the rule, as accepted by MobileDetect.Is, e.g. iphone for IPHONE.
+func KeyName(key int) (string, bool) {
+	return defaultRules.keyName(key)
+}
+
+// Rule returns the regular expression of the rule, without the flags it is compiled with, (?is).
+func Rule(key int) (string, bool) {
+	return defaultRules.rule(key)
+}
+
+func (r *rules) keyName(key int) (string, bool) {
+	for name, k := range r.namesKeys {
+		if k == key {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (r *rules) rule(key int) (string, bool) {
+	if key < 0 || key >= len(r.combined) {
+		return "", false
+	}
+	return r.combined[key], true
+}
+
+// RulesFingerprint identifies the default rules: it changes whenever one of them does, so that results can be
+// traced back to the rules which produced them.
+func RulesFingerprint() string {
+	return defaultRules.fingerprint()
+}
+
+// RulesCount returns the number of default rules.
+func RulesCount() int {
+	return len(defaultRules.combined)
+}
+
+func (r *rules) fingerprint() string {
+	r.fingerprintOnce.Do(func() {
+		h := sha256.New()
+		for _, rule := range r.combined {
+			io.WriteString(h, rule)
+			h.Write([]byte{0})
+		}
+		r.hash = hex.EncodeToString(h.Sum(nil))[:16]
+	})
+	return r.hash
+}
+
 func (r *rules) nameToKey(name string) (int, bool) {
 	key, ok := r.namesKeys[name]
 	return key, ok
@@ -927,5 +1032,11 @@ func (r *rules) setMobileDetectionRules(nameToKey map[string]int) {
 		j += 1
 	}
 
+	count = len(r.utilities)
+	for i := 0; i < count; i++ {
+		combined[j] = r.utilities[i]
+		j += 1
+	}
+
 	r.combined = combined
 }
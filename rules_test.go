@@ -4,10 +4,49 @@ import "testing"
 
 func TestGetMobileDetectionRules(t *testing.T) {
 	rules := NewRules()
-	count := len(rules.phoneDevices) + len(rules.tabletDevices) + len(rules.operatingSystems) + len(rules.browsers)
+	count := len(rules.phoneDevices) + len(rules.tabletDevices) + len(rules.operatingSystems) + len(rules.browsers) + len(rules.utilities)
 	values := rules.mobileDetectionRules()
 	valuesLength := len(values)
 	if count != valuesLength {
 		t.Errorf("Values length should be the same (count %d, values %d)", count, valuesLength)
 	}
 }
+
+func TestKeyName(t *testing.T) {
+	rules := NewRules()
+	seen := make(map[int]string)
+	for name, key := range rules.namesKeys {
+		if other, ok := seen[key]; ok {
+			t.Errorf("Key %d is named both %s and %s", key, name, other)
+		}
+		seen[key] = name
+	}
+	if name, ok := KeyName(IPHONE); !ok || "iphone" != name {
+		t.Errorf("Expected iphone got %s", name)
+	}
+	if _, ok := KeyName(-1); ok {
+		t.Error("Unknown keys have no name")
+	}
+	if rule, ok := Rule(BOLT); !ok || "bolt" != rule {
+		t.Errorf("Expected the bolt rule got %s", rule)
+	}
+	if _, ok := Rule(len(rules.mobileDetectionRules())); ok {
+		t.Error("Unknown keys have no rule")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	rules := NewRules()
+	if fingerprint := RulesFingerprint(); 16 != len(fingerprint) || fingerprint != NewRules().fingerprint() {
+		t.Errorf("Fingerprints of the same rules should be the same, got %s", fingerprint)
+	}
+	other := NewRules()
+	other.combined = append([]string{}, other.combined...)
+	other.combined[BOLT] = "bolt|bolt browser"
+	if rules.fingerprint() == other.fingerprint() {
+		t.Error("Fingerprints of different rules should differ")
+	}
+	if len(rules.mobileDetectionRules()) != RulesCount() {
+		t.Errorf("Unexpected number of rules %d", RulesCount())
+	}
+}
@@ -0,0 +1,53 @@
+//go:build go1.21
+
+package mobiledetect
+
+import (
+	"context"
+	"log/slog"
+)
+
+// The key WithLogger logs the detection result under.
+const LOG_KEY = "device"
+
+const loggerContextKey contextKey = 1
+
+// LogValue logs the result as a group of its device class, operating system, browser, grade and whether it is a bot,
+// the unknown ones being empty; identifiers of devices are not logged:
+//
+//	logger.Info("request", "device", res)
+func (res *Result) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("class", res.Device()),
+		slog.String("os", res.OS),
+		slog.String("os_version", res.OSVersion),
+		slog.String("browser", res.Browser),
+		slog.String("browser_version", res.BrowserVersion),
+		slog.String("grade", res.Grade),
+		slog.Bool("bot", res.IsBot()),
+	)
+}
+
+// WithLogger makes Handler, HandlerMux, Middleware and Redirect store in the request context a logger
+// derived from logger, or from slog.Default() when it is nil, which logs the result under LOG_KEY with
+// every record. Handlers get it from LoggerFromContext.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.details = true
+		o.contextHooks = append(o.contextHooks, func(ctx context.Context, res *Result) context.Context {
+			l := logger
+			if nil == l {
+				l = slog.Default()
+			}
+			return context.WithValue(ctx, loggerContextKey, l.With(slog.Any(LOG_KEY, res)))
+		})
+	}
+}
+
+// LoggerFromContext returns the logger stored by WithLogger in ctx, or slog.Default() if there is none.
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
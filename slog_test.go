@@ -0,0 +1,75 @@
+//go:build go1.21
+
+package mobiledetect
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResultLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	logger.Info("request", "device", NewDetector().Detect(r))
+
+	var record struct {
+		Device map[string]interface{}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); nil != err {
+		t.Fatalf("Invalid record %s (%v)", buf.String(), err)
+	}
+	expected := map[string]interface{}{"class": DEVICE_MOBILE, "os": "iOS", "os_version": "6_0_1", "browser": "Safari", "browser_version": "6.0", "grade": "A", "bot": false}
+	if len(expected) != len(record.Device) {
+		t.Errorf("Expected %v got %v", expected, record.Device)
+	}
+	for key, value := range expected {
+		if value != record.Device[key] {
+			t.Errorf("Expected %s=%v got %v", key, value, record.Device[key])
+		}
+	}
+
+	buf.Reset()
+	r.Header.Set("User-Agent", desktopUserAgent)
+	logger.Info("request", "device", NewDetector().Detect(r))
+	record.Device = nil
+	if err := json.Unmarshal(buf.Bytes(), &record); nil != err {
+		t.Fatalf("Invalid record %s (%v)", buf.String(), err)
+	}
+	if os, ok := record.Device["os"]; !ok || "" != os || len(expected) != len(record.Device) {
+		t.Errorf("Expected every field, with an empty OS for desktops, in %s", buf.String())
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	handler := Middleware(WithLogger(logger))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		LoggerFromContext(r.Context()).Info("served")
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", googlebotUserAgent)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var record struct {
+		Msg    string
+		Device struct {
+			Class string
+			Bot   bool
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); nil != err {
+		t.Fatalf("Invalid record %s (%v)", buf.String(), err)
+	}
+	if "served" != record.Msg || DEVICE_DESKTOP != record.Device.Class || !record.Device.Bot {
+		t.Errorf("Unexpected record %s", buf.String())
+	}
+	if slog.Default() != LoggerFromContext(httptest.NewRequest("GET", "/", nil).Context()) {
+		t.Errorf("Expected the default logger without WithLogger")
+	}
+}
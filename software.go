@@ -0,0 +1,76 @@
+package mobiledetect
+
+// software names an operating system or a browser rule, along with the property holding its version (-1 for none).
+type software struct {
+	key      int
+	name     string
+	property int
+}
+
+var (
+	// operating systems by priority: some User-Agents claim several, e.g. Windows Phone ones mention Android and iPhone too
+	operatingSystemNames = []software{
+		{WINDOWSPHONEOS, "WindowsPhoneOS", PROP_WINDOWS_PHONE_OS},
+		{WINDOWSMOBILEOS, "WindowsMobileOS", PROP_WINDOWS_CE},
+		{BLACKBERRYOS, "BlackBerryOS", PROP_BLACKBERRY},
+		{SYMBIANOS, "SymbianOS", PROP_SYMBIAN},
+		{WEBOS, "webOS", PROP_WEBOS},
+		{BADAOS, "badaOS", -1},
+		{BREWOS, "BREWOS", PROP_BREW},
+		{MEEGOOS, "MeeGoOS", -1},
+		{MAEMOOS, "MaemoOS", -1},
+		{JAVAOS, "JavaOS", PROP_JAVA},
+		{PALMOS, "PalmOS", -1},
+		{IOS, "iOS", PROP_IOS},
+		{ANDROIDOS, "AndroidOS", PROP_ANDROID},
+	}
+	// browsers by priority: the ones built on Chrome or Safari mention them as well
+	browserNames = []software{
+		{EDGE, "Edge", -1},
+		{OPERA, "Opera", PROP_OPERA},
+		{UCBROWSER, "UCBrowser", PROP_UC_BROWSER},
+		{BAIDUBOXAPP, "baiduboxapp", PROP_BAIDUBOXAPP},
+		{BAIDUBROWSER, "baidubrowser", PROP_BAIDUBROWSER},
+		{DIIGOBROWSER, "DiigoBrowser", -1},
+		{PUFFIN, "Puffin", -1},
+		{MERCURY, "Mercury", -1},
+		{OBIGOBROWSER, "ObigoBrowser", -1},
+		{NETFRONT, "NetFront", PROP_NETFRONT},
+		{PALEMOON, "PaleMoon", -1},
+		{SKYFIRE, "Skyfire", PROP_SKYFIRE},
+		{DOLFIN, "Dolfin", PROP_DOLFIN},
+		{BOLT, "Bolt", -1},
+		{TEASHARK, "TeaShark", -1},
+		{BLAZER, "Blazer", -1},
+		{TIZEN, "Tizen", PROP_TIZEN},
+		{IE, "IE", PROP_IE},
+		{FIREFOX, "Firefox", PROP_FIREFOX},
+		{CHROME, "Chrome", PROP_CHROME},
+		{SAFARI, "Safari", PROP_SAFARI},
+		{GENERICBROWSER, "GenericBrowser", -1},
+	}
+)
+
+// OS returns the name of the mobile operating system, e.g. AndroidOS or iOS, and its version if known.
+// Both are empty when no rule matches, as for desktops.
+func (md *MobileDetect) OS() (string, string) {
+	return md.software(operatingSystemNames)
+}
+
+// Browser returns the name of the mobile browser, e.g. Chrome or Opera, and its version if known.
+// Both are empty when no rule matches, as for desktop browsers.
+func (md *MobileDetect) Browser() (string, string) {
+	return md.software(browserNames)
+}
+
+func (md *MobileDetect) software(names []software) (string, string) {
+	for _, s := range names {
+		if md.IsKey(s.key) {
+			if s.property < 0 {
+				return s.name, ""
+			}
+			return s.name, md.VersionKey(s.property)
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,25 @@
+package mobiledetect
+
+import "testing"
+
+func TestSoftware(t *testing.T) {
+	data := []struct {
+		userAgent                              string
+		os, osVersion, browser, browserVersion string
+	}{
+		{iPhoneUserAgent, "iOS", "6_0_1", "Safari", "6.0"},
+		{iPadUserAgent, "iOS", "5_1_1", "Chrome", "21.0.1180.80"},
+		{`Mozilla/5.0 (Linux; Android 4.4.2; Nexus 5 Build/KOT49H) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/32.0.1700.99 Mobile Safari/537.36`, "AndroidOS", "4.4.2", "Chrome", "32.0.1700.99"},
+		{`Mozilla/5.0 (Mobile; Windows Phone 8.1; Android 4.0; ARM; Trident/7.0; Touch; rv:11.0; IEMobile/11.0; NOKIA; Lumia 635) like iPhone OS 7_0_3 Mac OS X AppleWebKit/537 (KHTML, like Gecko) Mobile Safari/537`, "WindowsPhoneOS", "8.1", "IE", "11.0"},
+		{`Opera/9.80 (Android; Opera Mini/7.5.33361/31.1448; U; en) Presto/2.8.119 Version/11.1010`, "AndroidOS", "", "Opera", "7.5.33361"},
+		{desktopUserAgent, "", "", "", ""},
+	}
+	for _, d := range data {
+		detect := NewMobileDetect(httpRequest, nil)
+		detect.SetUserAgent(d.userAgent)
+		res := detect.Result()
+		if d.os != res.OS || d.osVersion != res.OSVersion || d.browser != res.Browser || d.browserVersion != res.BrowserVersion {
+			t.Errorf("%s: expected %s %s %s %s got %s %s %s %s", d.userAgent, d.os, d.osVersion, d.browser, d.browserVersion, res.OS, res.OSVersion, res.Browser, res.BrowserVersion)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package mobiledetect
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Templates picks the variant of a template matching the device of a request: for index.html, tablets get
+// index.tablet.html, or else index.mobile.html, or else index.html; phones get index.mobile.html, or else index.html.
+// Variants are looked up in an html/template set, see NewTemplates, or parsed from files, see NewTemplatesFS.
+// Files are parsed once, the first time they are needed, and a Templates is safe for concurrent use.
+type Templates struct {
+	set    *template.Template
+	fsys   fs.FS
+	shared []string
+	funcs  template.FuncMap
+
+	mu     sync.RWMutex
+	loaded map[string]*template.Template
+}
+
+// NewTemplates looks the variants up in the templates associated with set.
+func NewTemplates(set *template.Template) *Templates {
+	return &Templates{set: set, loaded: make(map[string]*template.Template)}
+}
+
+// NewTemplatesFS parses the variants from the files of fsys, the first time they are needed. The files
+// matching the shared patterns, such as layouts or partials, are parsed along with every variant, before it.
+func NewTemplatesFS(fsys fs.FS, shared ...string) *Templates {
+	return &Templates{fsys: fsys, shared: shared, loaded: make(map[string]*template.Template)}
+}
+
+// Funcs adds the functions to the ones available to the templates parsed from files. It must be called before
+// the templates are first used.
+func (t *Templates) Funcs(funcMap template.FuncMap) *Templates {
+	if nil == t.funcs {
+		t.funcs = make(template.FuncMap, len(funcMap))
+	}
+	for name, f := range funcMap {
+		t.funcs[name] = f
+	}
+	return t
+}
+
+// Lookup returns the most specific variant of the template for the device. A nil result stands for a desktop.
+// The error wraps fs.ErrNotExist when there is no variant at all.
+func (t *Templates) Lookup(res *Result, name string) (*template.Template, error) {
+	device := DEVICE_DESKTOP
+	if nil != res {
+		device = res.Device()
+	}
+	for _, variant := range templateVariants(device, name) {
+		tmpl, err := t.cached(variant)
+		if nil != err {
+			return nil, err
+		}
+		if nil != tmpl {
+			return tmpl, nil
+		}
+	}
+	return nil, fmt.Errorf("mobiledetect: template %s: %w", name, fs.ErrNotExist)
+}
+
+// Execute applies the variant of the template matching the device of the request (see FromContext) to data.
+func (t *Templates) Execute(w io.Writer, r *http.Request, name string, data interface{}) error {
+	res, _ := FromContext(r.Context())
+	tmpl, err := t.Lookup(res, name)
+	if nil != err {
+		return err
+	}
+	return tmpl.Execute(w, data)
+}
+
+// cached returns the template named variant, or nil if there is none, parsing files only the first time.
+func (t *Templates) cached(variant string) (*template.Template, error) {
+	if nil != t.set {
+		return t.set.Lookup(variant), nil
+	}
+	t.mu.RLock()
+	tmpl, ok := t.loaded[variant]
+	t.mu.RUnlock()
+	if ok {
+		return tmpl, nil
+	}
+	tmpl, err := t.load(variant)
+	if nil != err {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.loaded[variant] = tmpl
+	t.mu.Unlock()
+	return tmpl, nil
+}
+
+// load parses the file named variant, if there is one.
+func (t *Templates) load(variant string) (*template.Template, error) {
+	if _, err := fs.Stat(t.fsys, variant); nil != err {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	tmpl := template.New(path.Base(variant)).Funcs(t.funcs)
+	if 0 != len(t.shared) {
+		if _, err := tmpl.ParseFS(t.fsys, t.shared...); nil != err {
+			return nil, err
+		}
+	}
+	return tmpl.ParseFS(t.fsys, variant)
+}
+
+// templateVariants returns the names of the variants of the template for the device, the most specific first.
+func templateVariants(device, name string) []string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	mobile := base + ".mobile" + ext
+	switch device {
+	case DEVICE_TABLET:
+		return []string{base + ".tablet" + ext, mobile, name}
+	case DEVICE_MOBILE:
+		return []string{mobile, name}
+	}
+	return []string{name}
+}
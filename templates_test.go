@@ -0,0 +1,116 @@
+package mobiledetect
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplateVariants(t *testing.T) {
+	data := []struct {
+		device, name string
+		expected     string
+	}{
+		{DEVICE_TABLET, "index.html", "index.tablet.html index.mobile.html index.html"},
+		{DEVICE_MOBILE, "pages/index.html", "pages/index.mobile.html pages/index.html"},
+		{DEVICE_DESKTOP, "index.html", "index.html"},
+		{DEVICE_MOBILE, "index", "index.mobile index"},
+	}
+	for _, d := range data {
+		if actual := strings.Join(templateVariants(d.device, d.name), " "); d.expected != actual {
+			t.Errorf("%s %s: expected %q got %q", d.device, d.name, d.expected, actual)
+		}
+	}
+}
+
+func executeTemplate(t *testing.T, templates *Templates, res *Result, name string) string {
+	tmpl, err := templates.Lookup(res, name)
+	if nil != err {
+		t.Fatalf("%s: %v", name, err)
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, "data"); nil != err {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return buf.String()
+}
+
+func TestTemplatesSet(t *testing.T) {
+	set := template.Must(template.New("index.html").Parse(`desktop {{.}}`))
+	template.Must(set.New("index.mobile.html").Parse(`mobile {{.}}`))
+	templates := NewTemplates(set)
+
+	data := []struct {
+		res      *Result
+		expected string
+	}{
+		{nil, "desktop data"},
+		{&Result{}, "desktop data"},
+		{&Result{Mobile: true}, "mobile data"},
+		{&Result{Mobile: true, Tablet: true}, "mobile data"},
+	}
+	for _, d := range data {
+		if actual := executeTemplate(t, templates, d.res, "index.html"); d.expected != actual {
+			t.Errorf("%+v: expected %q got %q", d.res, d.expected, actual)
+		}
+	}
+
+	if _, err := templates.Lookup(nil, "missing.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestTemplatesFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.html":             {Data: []byte(`{{define "layout"}}[{{block "content" .}}{{end}}]{{end}}`)},
+		"pages/index.html":        {Data: []byte(`{{template "layout" .}}{{define "content"}}desktop {{shout .}}{{end}}`)},
+		"pages/index.tablet.html": {Data: []byte(`{{template "layout" .}}{{define "content"}}tablet {{shout .}}{{end}}`)},
+		"pages/broken.html":       {Data: []byte(`{{if}}`)},
+	}
+	templates := NewTemplatesFS(fsys, "layout.html").Funcs(template.FuncMap{"shout": strings.ToUpper})
+
+	data := []struct {
+		res      *Result
+		expected string
+	}{
+		{&Result{}, "[desktop DATA]"},
+		{&Result{Mobile: true}, "[desktop DATA]"},
+		{&Result{Mobile: true, Tablet: true}, "[tablet DATA]"},
+	}
+	for _, d := range data {
+		if actual := executeTemplate(t, templates, d.res, "pages/index.html"); d.expected != actual {
+			t.Errorf("%+v: expected %q got %q", d.res, d.expected, actual)
+		}
+	}
+
+	// parsed files are kept, later changes are not seen
+	fsys["pages/index.html"] = &fstest.MapFile{Data: []byte(`changed`)}
+	if actual := executeTemplate(t, templates, &Result{}, "pages/index.html"); "[desktop DATA]" != actual {
+		t.Errorf("Parsed templates should be cached, got %q", actual)
+	}
+
+	if _, err := templates.Lookup(nil, "pages/broken.html"); nil == err || errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a parse error, got %v", err)
+	}
+	if _, err := templates.Lookup(&Result{Mobile: true}, "pages/missing.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestTemplatesExecute(t *testing.T) {
+	set := template.Must(template.New("index.html").Parse(`desktop`))
+	template.Must(set.New("index.mobile.html").Parse(`mobile`))
+	templates := NewTemplates(set)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(NewContext(r.Context(), &Result{Mobile: true}))
+	w := httptest.NewRecorder()
+	if err := templates.Execute(w, r, "index.html", nil); nil != err || "mobile" != w.Body.String() {
+		t.Errorf("Expected the mobile variant, got %q (%v)", w.Body.String(), err)
+	}
+}
@@ -0,0 +1,97 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"strings"
+)
+
+// The response header WithDeviceClassHeader is usually given.
+const DEVICE_CLASS_HEADER = "X-Device-Class"
+
+// WithVary tells whether Handler, HandlerMux, Middleware and Redirect add the Vary response header, which they do
+// by default. It lists every input of the detection: the User-Agent, the trusted headers (see WithTrustedHeaders)
+// the result depends on and the override sources (see WithOverride), so that caches never serve a response meant
+// for another device.
+func WithVary(enabled bool) Option {
+	return func(o *options) {
+		o.noVary = !enabled
+	}
+}
+
+// WithDeviceClassHeader sets the response header name, such as DEVICE_CLASS_HEADER, to the class of the device:
+// mobile, tablet or desktop. Edge caches can key on it, or log it, without parsing the User-Agent themselves.
+func WithDeviceClassHeader(name string) Option {
+	return func(o *options) {
+		o.deviceClassHeader = name
+	}
+}
+
+// varyOn returns the request headers which may change the result under the options.
+func (o *options) varyOn() []string {
+	names := []string{"User-Agent"}
+	inputs := o.inputHeaders()
+	for _, name := range o.trustedHeaders {
+		if hasToken(inputs, cgiHeaderName(name)) {
+			names = append(names, name)
+		}
+	}
+	if nil != o.forwarded {
+		names = append(names, o.forwarded.Headers...)
+	}
+	if nil != o.override {
+		if "" != o.override.Header {
+			names = append(names, o.override.Header)
+		}
+		if "" != o.override.Cookie {
+			names = append(names, "Cookie")
+		}
+	}
+	return names
+}
+
+// setResponseHeaders adds the headers caches need to tell responses to different devices apart.
+func (o *options) setResponseHeaders(w http.ResponseWriter, res *Result) {
+	if !o.noVary {
+		addVary(w.Header(), o.varyOn()...)
+	}
+	if "" != o.deviceClassHeader {
+		w.Header().Set(o.deviceClassHeader, strings.ToLower(res.Device()))
+	}
+}
+
+// addVary adds names to the Vary header of a response, unless they are there already.
+func addVary(header http.Header, names ...string) {
+	var missing []string
+	for _, name := range names {
+		if !hasToken(header.Values("Vary"), name) && !hasToken(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+	if 0 != len(missing) {
+		header.Add("Vary", strings.Join(missing, ", "))
+	}
+}
+
+// addCacheControl adds directive to the Cache-Control header of a response, unless it is there already.
+func addCacheControl(header http.Header, directive string) {
+	if hasToken(header.Values("Cache-Control"), directive) {
+		return
+	}
+	if value := header.Get("Cache-Control"); "" != value {
+		header.Set("Cache-Control", value+", "+directive)
+	} else {
+		header.Set("Cache-Control", directive)
+	}
+}
+
+// hasToken tells whether token is in one of the comma separated lists of values.
+func hasToken(values []string, token string) bool {
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
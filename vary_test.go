@@ -0,0 +1,80 @@
+package mobiledetect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVary(t *testing.T) {
+	data := []struct {
+		opts     []Option
+		expected string
+		device   string
+	}{
+		{nil, "User-Agent", ""},
+		{[]Option{WithTrustedHeaders(DefaultTrustedHeaders()...)}, "User-Agent, Accept, X-Wap-Profile, X-Wap-Clientid, Wap-Connection, Profile, X-Operamini-Phone-Ua, X-Nokia-Gateway-Id, X-Orange-Id, X-Vodafone-3gpdpcontext, X-Huawei-Userid, Ua-Os, X-Mobile-Gateway, X-Att-Deviceid, Ua-Cpu", ""},
+		// X-OperaMini-Features only changes the proxy mode
+		{[]Option{WithTrustedHeaders("Accept", "X-Operamini-Features"), WithDetails(true)}, "User-Agent, Accept, X-Operamini-Features", ""},
+		{[]Option{WithTrustedHeaders("Accept", "X-Requested-With", "X-Up-Subno"), WithCarriers(Carrier{Header: "X-Up-Subno", Name: "Openwave"})}, "User-Agent, Accept, X-Up-Subno", ""},
+		{[]Option{WithTrustedHeaders("Accept"), WithOverride(overrideConfig)}, "User-Agent, Accept, X-Force-Device, Cookie", ""},
+		{[]Option{WithTrustedHeaders(), WithForwardedUserAgent(ForwardedUserAgentConfig{})}, "User-Agent, X-Operamini-Phone-Ua, Device-Stock-Ua, X-Original-User-Agent", ""},
+		{[]Option{WithVary(false), WithDeviceClassHeader(DEVICE_CLASS_HEADER)}, "", "mobile"},
+	}
+	for _, d := range data {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("User-Agent", iPhoneUserAgent)
+		w := httptest.NewRecorder()
+		Middleware(d.opts...)(http.NotFoundHandler()).ServeHTTP(w, r)
+		if vary := w.Header().Get("Vary"); d.expected != vary {
+			t.Errorf("Expected Vary %q got %q", d.expected, vary)
+		}
+		if device := w.Header().Get(DEVICE_CLASS_HEADER); d.device != device {
+			t.Errorf("Expected %s %q got %q", DEVICE_CLASS_HEADER, d.device, device)
+		}
+	}
+}
+
+func TestVaryHandler(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?device=tablet", nil)
+	r.Header.Set("User-Agent", iPhoneUserAgent)
+	w := httptest.NewRecorder()
+	opts := []Option{WithTrustedHeaders(), WithOverride(overrideConfig), WithDeviceClassHeader("X-Class")}
+	Handler(&basicMethodsStruct{}, nil, opts...).ServeHTTP(w, r)
+	if "User-Agent, X-Force-Device, Cookie" != w.Header().Get("Vary") {
+		t.Errorf("Unexpected Vary %q", w.Header().Get("Vary"))
+	}
+	if "tablet" != w.Header().Get("X-Class") {
+		t.Errorf("The header should hold the forced class, got %q", w.Header().Get("X-Class"))
+	}
+	if "private" != w.Header().Get("Cache-Control") {
+		t.Errorf("Setting the override cookie should make the response private, got %q", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	header := http.Header{}
+	header.Set("Vary", "Accept-Encoding, user-agent")
+	addVary(header, "User-Agent", "Cookie", "Cookie", "Accept")
+	if vary := header.Values("Vary"); 2 != len(vary) || "Cookie, Accept" != vary[1] {
+		t.Errorf("Unexpected Vary %v", vary)
+	}
+	addVary(header, "accept")
+	if 2 != len(header.Values("Vary")) {
+		t.Errorf("Unexpected Vary %v", header.Values("Vary"))
+	}
+}
+
+func TestAddCacheControl(t *testing.T) {
+	header := http.Header{}
+	addCacheControl(header, "private")
+	addCacheControl(header, "Private")
+	if "private" != header.Get("Cache-Control") {
+		t.Errorf("Unexpected Cache-Control %q", header.Get("Cache-Control"))
+	}
+	header.Set("Cache-Control", "max-age=60")
+	addCacheControl(header, "private")
+	if "max-age=60, private" != header.Get("Cache-Control") {
+		t.Errorf("Unexpected Cache-Control %q", header.Get("Cache-Control"))
+	}
+}